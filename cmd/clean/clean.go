@@ -2,15 +2,20 @@
 package clean
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
-	"os"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/brpaz/freshrss-cleaner/cmd/cmdutil"
 	"github.com/brpaz/freshrss-cleaner/internal/config"
 	"github.com/brpaz/freshrss-cleaner/internal/freshrss"
-	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+	freshrssclient "github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+	"github.com/brpaz/freshrss-cleaner/internal/logging"
 )
 
 // New creates a new clean command
@@ -21,59 +26,169 @@ func New() *cobra.Command {
 		RunE:  runClean,
 	}
 
-	cmd.Flags().StringP("config", "c", config.DefaultConfigFilePath(), "Path to the configuration file")
+	cmdutil.AddConfigFlag(cmd)
+	cmd.Flags().Bool("dry-run", false, "Report the items that would be marked as read, without changing anything")
+	cmd.Flags().String("failure-policy", "", "How to handle feeds failing to be processed: continue, fail-fast or fail-at-end (overrides the config file)")
 
 	return cmd
 }
 
 // runClean handles the execution of the clean command
 func runClean(cmd *cobra.Command, args []string) error {
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	logger := logging.FromContext(cmd.Context())
 
-	// Get configuration path
-	configPath, err := cmd.Flags().GetString("config")
+	dryRun, err := cmd.Flags().GetBool("dry-run")
 	if err != nil {
-		return fmt.Errorf("failed to get config flag: %w", err)
+		return fmt.Errorf("failed to get dry-run flag: %w", err)
 	}
 
-	// Load configuration
-	cfg, err := config.Load(configPath)
+	failurePolicy, err := cmd.Flags().GetString("failure-policy")
 	if err != nil {
-		return fmt.Errorf("failed to load config file: %w", err)
+		return fmt.Errorf("failed to get failure-policy flag: %w", err)
 	}
 
-	// Initialize FreshRSS client
-	client, err := createFreshRSSClient(cfg)
+	// Flags are valid at this point, so there is no need to print the usage on errors
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd, failurePolicy)
 	if err != nil {
 		return err
 	}
 
-	// Run the cleaner
-	cleaner, err := freshrss.NewCleaner(
-		freshrss.WithClient(client),
-		freshrss.WithConfig(cfg),
-	)
+	cleaner, err := newCleaner(cfg, logger, dryRun)
 	if err != nil {
-		return fmt.Errorf("failed to create cleaner: %w", err)
+		return err
 	}
 
 	ctx := cmd.Context()
-	if err := cleaner.CleanOldEntries(ctx, logger); err != nil {
+	result, err := cleaner.CleanOldEntries(ctx, logger)
+	if errors.Is(err, freshrss.ErrAuthentication) {
+		return cmdutil.WithExitCode(cmdutil.ExitAuthError, err)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to run cleaner: %w", err)
 	}
 
-	return nil
+	if err := printSummary(cmd.OutOrStdout(), cfg, result, dryRun); err != nil {
+		return fmt.Errorf("failed to print result: %w", err)
+	}
+
+	return resultError(result, cfg.FailurePolicy.OrDefault())
 }
 
-// createFreshRSSClient initializes a new FreshRSS client with configuration
-func createFreshRSSClient(cfg *config.RootConfig) (*client.Client, error) {
-	client, err := client.New(
-		client.WithBaseURL(cfg.URL),
-		client.WithCredentials(cfg.Username, cfg.Password),
+// loadConfig loads and validates the configuration, overriding its failure policy with the one of the flag, if set
+func loadConfig(cmd *cobra.Command, failurePolicy string) (*config.RootConfig, error) {
+	cfg, err := cmdutil.LoadValidConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if failurePolicy != "" {
+		cfg.FailurePolicy = config.FailurePolicy(failurePolicy)
+	}
+
+	if err := cfg.FailurePolicy.Validate(); err != nil {
+		return nil, cmdutil.WithExitCode(cmdutil.ExitConfigError, err)
+	}
+
+	return cfg, nil
+}
+
+// newCleaner initializes the FreshRSS client and the cleaner running the rules of the configuration
+func newCleaner(cfg *config.RootConfig, logger *slog.Logger, dryRun bool) (*freshrss.Cleaner, error) {
+	client, err := cmdutil.NewClient(cfg, freshrssclient.WithLogger(logger))
+	if err != nil {
+		return nil, err
+	}
+
+	journalOption, err := cmdutil.JournalOption(cfg, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	cleaner, err := freshrss.NewCleaner(
+		freshrss.WithClient(client),
+		freshrss.WithConfig(cfg),
+		cmdutil.TokenCacheOption(cfg),
+		journalOption,
+		freshrss.WithDryRun(dryRun),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create freshrss client: %w", err)
+		return nil, fmt.Errorf("failed to create cleaner: %w", err)
+	}
+
+	return cleaner, nil
+}
+
+// printSummary writes the summary of the run and, when items were recorded in the journal, how to undo it
+func printSummary(out io.Writer, cfg *config.RootConfig, result *freshrss.Result, dryRun bool) error {
+	if err := printResult(out, result, dryRun); err != nil {
+		return err
+	}
+
+	if !cfg.Journal.IsEnabled() || dryRun || result.Marked() == 0 {
+		return nil
 	}
 
-	return client, nil
+	_, err := fmt.Fprintf(out, "To mark these items as unread again, run: freshrss-cleaner undo %s\n", result.RunID)
+
+	return err
+}
+
+// resultError returns the error matching the outcome of the run, according to the failure policy.
+// Runs where every feed failed (or was skipped after a failure) return ExitTotalFailure, other failed runs return ExitPartialFailure.
+func resultError(result *freshrss.Result, policy config.FailurePolicy) error {
+	failed := result.Failed()
+	if failed == 0 || policy == config.FailurePolicyContinue {
+		return nil
+	}
+
+	err := fmt.Errorf("%d of %d feeds failed to be processed", failed, len(result.Feeds))
+	if failed == len(result.Feeds) {
+		return cmdutil.WithExitCode(cmdutil.ExitTotalFailure, err)
+	}
+
+	return cmdutil.WithExitCode(cmdutil.ExitPartialFailure, err)
+}
+
+// printResult writes a summary table of the run, with a row per feed. In dry-run mode, the items are reported as would be marked as read.
+func printResult(out io.Writer, result *freshrss.Result, dryRun bool) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FEED\tRULE\tUNREAD BEFORE\tUNREAD AFTER\tMARKED\tSPARED\tDURATION\tSTATUS")
+	for _, feed := range result.Feeds {
+		status := "ok"
+		if feed.Err != nil {
+			status = "error: " + feed.Err.Error()
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%s\t%s\n",
+			feed.FeedID,
+			feed.Rule,
+			feed.UnreadBefore,
+			feed.UnreadAfter,
+			feed.Marked,
+			feed.Spared,
+			feed.Duration.Round(time.Millisecond),
+			status,
+		)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	marked := "marked"
+	if dryRun {
+		marked = "would be marked"
+	}
+
+	_, err := fmt.Fprintf(out, "\n%d items %s as read in %s, %d of %d feeds failed\n",
+		result.Marked(),
+		marked,
+		result.Duration.Round(time.Millisecond),
+		result.Failed(),
+		len(result.Feeds),
+	)
+
+	return err
 }
@@ -0,0 +1,126 @@
+package clean
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brpaz/freshrss-cleaner/cmd/cmdutil"
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss"
+)
+
+func TestResultError(t *testing.T) {
+	t.Parallel()
+
+	ok := freshrss.FeedResult{FeedID: "feed1"}
+	failed := freshrss.FeedResult{FeedID: "feed2", Err: assert.AnError}
+
+	tests := []struct {
+		name         string
+		feeds        []freshrss.FeedResult
+		policy       config.FailurePolicy
+		expectedCode int
+		expectedErr  string
+	}{
+		{name: "WithoutFailures", feeds: []freshrss.FeedResult{ok, ok}, policy: config.FailurePolicyFailAtEnd, expectedCode: cmdutil.ExitOK},
+		{name: "WithPartialFailure", feeds: []freshrss.FeedResult{ok, failed}, policy: config.FailurePolicyFailAtEnd, expectedCode: cmdutil.ExitPartialFailure, expectedErr: "1 of 2 feeds failed to be processed"},
+		{name: "WithTotalFailure", feeds: []freshrss.FeedResult{failed, failed, failed}, policy: config.FailurePolicyFailAtEnd, expectedCode: cmdutil.ExitTotalFailure, expectedErr: "3 of 3 feeds failed to be processed"},
+		{name: "WithFailFastOnFirstFeed", feeds: []freshrss.FeedResult{failed}, policy: config.FailurePolicyFailFast, expectedCode: cmdutil.ExitTotalFailure},
+		{name: "WithFailFastAfterFirstFeed", feeds: []freshrss.FeedResult{ok, failed}, policy: config.FailurePolicyFailFast, expectedCode: cmdutil.ExitPartialFailure},
+		{name: "WithContinue", feeds: []freshrss.FeedResult{failed, failed}, policy: config.FailurePolicyContinue, expectedCode: cmdutil.ExitOK},
+	}
+
+	for _, tc := range tests {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := resultError(&freshrss.Result{Feeds: tc.feeds}, tc.policy)
+			assert.Equal(t, tc.expectedCode, cmdutil.ExitCode(err))
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestPrintResult(t *testing.T) {
+	t.Parallel()
+
+	result := &freshrss.Result{
+		Feeds:    []freshrss.FeedResult{{FeedID: "feed1", Rule: "days=7", Marked: 3}},
+		Duration: time.Second,
+	}
+
+	tests := []struct {
+		name           string
+		dryRun         bool
+		expectedFooter string
+	}{
+		{name: "WithoutDryRun", expectedFooter: "3 items marked as read in 1s, 0 of 1 feeds failed\n"},
+		{name: "WithDryRun", dryRun: true, expectedFooter: "3 items would be marked as read in 1s, 0 of 1 feeds failed\n"},
+	}
+
+	for _, tc := range tests {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := bytes.NewBufferString("")
+			require.NoError(t, printResult(b, result, tc.dryRun))
+			assert.True(t, strings.HasSuffix(b.String(), "\n\n"+tc.expectedFooter), b.String())
+		})
+	}
+}
+
+func TestCleanCmd_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		rules    string
+		errorMsg string
+	}{
+		{
+			name:     "WithEmptyDefaultRule",
+			rules:    "default: {}\n",
+			errorMsg: "default: one of days, older_than, keep_unread, match or exclude is required",
+		},
+		{
+			name:     "WithNegativeDays",
+			rules:    "feeds:\n  - id: feed/1\n    days: -3\n",
+			errorMsg: `line 6: feed "feed/1": days must be greater than zero`,
+		},
+		{
+			name:     "WithZeroOlderThan",
+			rules:    "feeds:\n  - id: feed/1\n    older_than: 0s\n",
+			errorMsg: `line 6: feed "feed/1": older_than must be greater than zero`,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			configPath := filepath.Join(t.TempDir(), "freshrss-cleaner.yaml")
+			err := os.WriteFile(configPath, []byte("url: https://freshrss.example.com\nusername: test\npassword: pass\n"+tc.rules), 0o600)
+			require.NoError(t, err)
+
+			cmd := New()
+			cmd.SetOut(bytes.NewBufferString(""))
+			cmd.SetArgs([]string{"--config", configPath})
+
+			err = cmd.Execute()
+			assert.ErrorContains(t, err, tc.errorMsg)
+			assert.Equal(t, cmdutil.ExitConfigError, cmdutil.ExitCode(err))
+		})
+	}
+}
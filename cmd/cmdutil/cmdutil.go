@@ -0,0 +1,159 @@
+// Package cmdutil provides helpers shared between the application commands.
+package cmdutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+	"github.com/brpaz/freshrss-cleaner/internal/journal"
+	"github.com/brpaz/freshrss-cleaner/internal/logging"
+	"github.com/brpaz/freshrss-cleaner/internal/tokencache"
+)
+
+// AddConfigFlag registers the flag used to specify the path of the configuration file
+func AddConfigFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringP("config", "c", config.DefaultConfigFilePath(), "Path to the configuration file")
+}
+
+// LoadConfig loads the configuration file specified by the config flag of the command.
+// Errors loading the file are reported with the ExitConfigError exit code.
+func LoadConfig(cmd *cobra.Command) (*config.RootConfig, error) {
+	configPath, err := cmd.Flags().GetString("config")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config flag: %w", err)
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, WithExitCode(ExitConfigError, fmt.Errorf("failed to load config file: %w", err))
+	}
+
+	return cfg, nil
+}
+
+// LoadValidConfig loads the configuration file specified by the config flag of the command, like LoadConfig, and checks its values
+// with config.Validate, so the commands marking items as read never apply invalid rules. Problems are reported with the ExitConfigError exit code.
+func LoadValidConfig(cmd *cobra.Command) (*config.RootConfig, error) {
+	configPath, err := cmd.Flags().GetString("config")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config flag: %w", err)
+	}
+
+	configData, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, WithExitCode(ExitConfigError, fmt.Errorf("failed to read config file %s: %w", configPath, err))
+	}
+
+	if problems := config.Validate(configData); len(problems) > 0 {
+		messages := make([]string, 0, len(problems))
+		for _, problem := range problems {
+			messages = append(messages, problem.String())
+		}
+
+		return nil, WithExitCode(ExitConfigError, fmt.Errorf("invalid config file %s: %s", configPath, strings.Join(messages, "; ")))
+	}
+
+	return LoadConfig(cmd)
+}
+
+// NewClient initializes a new FreshRSS client with configuration, and any additional options.
+// An invalid client configuration is reported with the ExitConfigError exit code.
+func NewClient(cfg *config.RootConfig, opts ...client.Option) (*client.Client, error) {
+	opts = append([]client.Option{
+		client.WithBaseURL(cfg.URL),
+		client.WithCredentials(cfg.Username, cfg.Password),
+		client.WithAuthToken(cfg.AuthToken),
+		client.WithRetryPolicy(RetryPolicy(cfg.Retry)),
+		client.WithRateLimit(cfg.RateLimit),
+	}, opts...)
+
+	c, err := client.New(opts...)
+	if err != nil {
+		return nil, WithExitCode(ExitConfigError, fmt.Errorf("failed to create freshrss client: %w", err))
+	}
+
+	return c, nil
+}
+
+// Login creates a FreshRSS client for the configuration and logs in, returning the client and the auth token.
+// Failures to log in are reported with the ExitAuthError exit code.
+func Login(ctx context.Context, cfg *config.RootConfig) (*client.Client, string, error) {
+	c, err := NewClient(cfg, client.WithLogger(logging.FromContext(ctx)))
+	if err != nil {
+		return nil, "", err
+	}
+
+	authToken, err := c.GetAuthToken(ctx)
+	if err != nil {
+		return nil, "", WithExitCode(ExitAuthError, fmt.Errorf("failed to get auth token: %w", err))
+	}
+
+	return c, authToken, nil
+}
+
+// RetryPolicy returns the client retry policy for the retry configuration, using the client defaults for the fields that are not set
+func RetryPolicy(cfg config.RetryConfig) client.RetryPolicy {
+	policy := client.DefaultRetryPolicy()
+
+	if cfg.MaxAttempts != 0 {
+		policy.MaxAttempts = cfg.MaxAttempts
+	}
+
+	if cfg.InitialBackoff != 0 {
+		policy.InitialBackoff = time.Duration(cfg.InitialBackoff)
+	}
+
+	if cfg.MaxBackoff != 0 {
+		policy.MaxBackoff = time.Duration(cfg.MaxBackoff)
+	}
+
+	if cfg.Jitter != nil {
+		policy.Jitter = *cfg.Jitter
+	}
+
+	return policy
+}
+
+// TokenCacheOption returns the cleaner option caching the auth token between runs, in the user cache directory.
+// The cache is disabled when a pre-obtained auth token is configured, or the cache directory is not available.
+func TokenCacheOption(cfg *config.RootConfig) freshrss.CleanerOption {
+	dir, err := tokencache.DefaultDir()
+	if cfg.AuthToken != "" || err != nil {
+		return func(*freshrss.Cleaner) {}
+	}
+
+	return freshrss.WithTokenStore(tokencache.New(dir, cfg.URL, cfg.Username))
+}
+
+// NewJournal returns the journal of the runs of the configured account, in the user data directory
+func NewJournal(cfg *config.RootConfig) (*journal.FileJournal, error) {
+	dir, err := journal.DefaultDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journal directory: %w", err)
+	}
+
+	return journal.New(dir, cfg.URL, cfg.Username, cfg.Journal.KeepRuns), nil
+}
+
+// JournalOption returns the cleaner option recording the items marked as read in the journal, unless it is disabled in the configuration.
+// Dry runs don't mark anything, so they don't need the journal either.
+func JournalOption(cfg *config.RootConfig, dryRun bool) (freshrss.CleanerOption, error) {
+	if !cfg.Journal.IsEnabled() || dryRun {
+		return func(*freshrss.Cleaner) {}, nil
+	}
+
+	j, err := NewJournal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return freshrss.WithJournal(j), nil
+}
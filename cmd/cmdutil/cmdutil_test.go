@@ -0,0 +1,36 @@
+package cmdutil_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brpaz/freshrss-cleaner/cmd/cmdutil"
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("UsesTheClientDefaults", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, client.DefaultRetryPolicy(), cmdutil.RetryPolicy(config.RetryConfig{}))
+	})
+
+	t.Run("OverridesTheConfiguredFields", func(t *testing.T) {
+		t.Parallel()
+		jitter := 0.0
+		policy := cmdutil.RetryPolicy(config.RetryConfig{
+			MaxAttempts: 5,
+			MaxBackoff:  config.Duration(time.Minute),
+			Jitter:      &jitter,
+		})
+
+		assert.Equal(t, 5, policy.MaxAttempts)
+		assert.Equal(t, client.DefaultRetryPolicy().InitialBackoff, policy.InitialBackoff)
+		assert.Equal(t, time.Minute, policy.MaxBackoff)
+		assert.Equal(t, 0.0, policy.Jitter)
+	})
+}
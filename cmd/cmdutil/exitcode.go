@@ -0,0 +1,52 @@
+package cmdutil
+
+import (
+	"errors"
+)
+
+// Exit codes returned by the application, so schedulers and monitoring tools can tell failures apart
+const (
+	ExitOK             = 0
+	ExitError          = 1
+	ExitConfigError    = 2
+	ExitAuthError      = 3
+	ExitPartialFailure = 4
+	ExitTotalFailure   = 5
+)
+
+// exitError is an error that carries the exit code the application should terminate with
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// WithExitCode wraps the error with the exit code the application should terminate with
+func WithExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &exitError{code: code, err: err}
+}
+
+// ExitCode returns the exit code for the specified error. Errors without an explicit exit code return ExitError.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+
+	return ExitError
+}
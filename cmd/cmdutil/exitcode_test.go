@@ -0,0 +1,25 @@
+package cmdutil_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/brpaz/freshrss-cleaner/cmd/cmdutil"
+)
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, cmdutil.ExitOK, cmdutil.ExitCode(nil))
+	assert.Equal(t, cmdutil.ExitError, cmdutil.ExitCode(assert.AnError))
+	assert.Equal(t, cmdutil.ExitAuthError, cmdutil.ExitCode(cmdutil.WithExitCode(cmdutil.ExitAuthError, assert.AnError)))
+
+	wrapped := fmt.Errorf("failed to run: %w", cmdutil.WithExitCode(cmdutil.ExitConfigError, assert.AnError))
+	assert.Equal(t, cmdutil.ExitConfigError, cmdutil.ExitCode(wrapped))
+	assert.ErrorIs(t, wrapped, assert.AnError)
+	assert.Equal(t, "failed to run: "+assert.AnError.Error(), wrapped.Error())
+
+	assert.Nil(t, cmdutil.WithExitCode(cmdutil.ExitAuthError, nil))
+}
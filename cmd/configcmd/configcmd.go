@@ -0,0 +1,112 @@
+// Package configcmd provides the command definitions to manage the configuration file.
+package configcmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brpaz/freshrss-cleaner/cmd/cmdutil"
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss"
+)
+
+// New creates a new config command, with the validate subcommand
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the configuration file",
+	}
+
+	cmdutil.AddConfigFlag(cmd)
+
+	cmd.AddCommand(newValidateCmd())
+
+	return cmd
+}
+
+// newValidateCmd creates the command that checks the configuration file for problems
+func newValidateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the configuration file for problems, like unknown fields, missing values or invalid feed rules",
+		Long: `Check the configuration file for problems, like unknown fields, missing values or invalid feed rules.
+
+With --check-remote, it also logs in to FreshRSS and checks that every feed exists on the server,
+suggesting the closest subscriptions, categories or labels for the ones that don't.`,
+		RunE: runValidate,
+	}
+
+	cmd.Flags().Bool("check-remote", false, "Log in to FreshRSS and check that every feed ID exists on the server")
+
+	return cmd
+}
+
+// runValidate handles the execution of the validate command
+func runValidate(cmd *cobra.Command, args []string) error {
+	configPath, err := cmd.Flags().GetString("config")
+	if err != nil {
+		return fmt.Errorf("failed to get config flag: %w", err)
+	}
+
+	checkRemote, err := cmd.Flags().GetBool("check-remote")
+	if err != nil {
+		return fmt.Errorf("failed to get check-remote flag: %w", err)
+	}
+
+	cmd.SilenceUsage = true
+
+	configData, err := os.ReadFile(configPath)
+	if err != nil {
+		return cmdutil.WithExitCode(cmdutil.ExitConfigError, fmt.Errorf("failed to read config file %s: %w", configPath, err))
+	}
+
+	out := cmd.OutOrStdout()
+
+	// The feeds are only checked on the server when the config file is usable
+	problems := config.Validate(configData)
+	if len(problems) == 0 && checkRemote {
+		problems, err = validateRemote(cmd.Context(), configPath, configData)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, problem := range problems {
+		if problem.Line > 0 {
+			fmt.Fprintf(out, "%s:%d: %s\n", configPath, problem.Line, problem.Message)
+		} else {
+			fmt.Fprintf(out, "%s: %s\n", configPath, problem.Message)
+		}
+	}
+
+	if len(problems) > 0 {
+		return cmdutil.WithExitCode(cmdutil.ExitConfigError, fmt.Errorf("found %d problems in config file %s", len(problems), configPath))
+	}
+
+	fmt.Fprintf(out, "config file %s is valid\n", configPath)
+
+	return nil
+}
+
+// validateRemote logs in to FreshRSS and checks that the feeds of the configuration exist on the server
+func validateRemote(ctx context.Context, configPath string, configData []byte) ([]config.Problem, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, cmdutil.WithExitCode(cmdutil.ExitConfigError, fmt.Errorf("failed to load config file: %w", err))
+	}
+
+	c, authToken, err := cmdutil.Login(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := freshrss.NewCatalog(ctx, c, authToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkFeeds(cfg.Feeds, config.FeedLines(configData), catalog), nil
+}
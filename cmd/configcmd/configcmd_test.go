@@ -0,0 +1,111 @@
+package configcmd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/brpaz/freshrss-cleaner/cmd/cmdutil"
+	"github.com/brpaz/freshrss-cleaner/cmd/configcmd"
+)
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	configPath := filepath.Join(t.TempDir(), "freshrss-cleaner.yaml")
+	err := os.WriteFile(configPath, []byte(content), 0o600)
+	require.NoError(t, err)
+
+	return configPath
+}
+
+func TestValidateCmd(t *testing.T) {
+	t.Run("With valid config file", func(t *testing.T) {
+		configPath := writeTestConfig(t, "url: https://example.com\nusername: user\npassword: pass\nfeeds:\n  - id: feed/1\n    days: 7\n")
+
+		cmd := configcmd.New()
+		b := bytes.NewBufferString("")
+		cmd.SetOut(b)
+		cmd.SetArgs([]string{"validate", "--config", configPath})
+
+		err := cmd.Execute()
+		require.NoError(t, err)
+		assert.Equal(t, "config file "+configPath+" is valid\n", b.String())
+	})
+
+	t.Run("With invalid config file", func(t *testing.T) {
+		configPath := writeTestConfig(t, "url: https://example.com\nusername: user\npassword: pass\nfeeds:\n  - id: feed/1\n    dayz: 7\n")
+
+		cmd := configcmd.New()
+		b := bytes.NewBufferString("")
+		cmd.SetOut(b)
+		cmd.SetErr(b)
+		cmd.SetArgs([]string{"validate", "--config", configPath})
+
+		err := cmd.Execute()
+		require.Error(t, err)
+		assert.Equal(t, cmdutil.ExitConfigError, cmdutil.ExitCode(err))
+		assert.Contains(t, b.String(), configPath+":6: field dayz not found in type config.FeedConfig")
+	})
+}
+
+func TestValidateCmd_CheckRemote(t *testing.T) {
+	defer gock.Off() // Flush pending mocks after test execution
+
+	gock.New("https://freshrss.example.com").
+		Post("/accounts/ClientLogin").
+		Reply(200).
+		BodyString("SID=test/auth-token\nLSID=null\nAuth=test/auth-token\n")
+	gock.New("https://freshrss.example.com").
+		Get("/reader/api/0/subscription/list").
+		Reply(200).
+		BodyString(`{"subscriptions":[{"id":"feed/22","title":"Hacker News","categories":[{"id":"user/-/label/News","label":"News"}]},{"id":"feed/7","title":"Go Blog"}]}`)
+	gock.New("https://freshrss.example.com").
+		Get("/reader/api/0/tag/list").
+		Reply(200).
+		BodyString(`{"tags":[{"id":"user/-/state/com.google/starred"},{"id":"user/-/label/News","type":"folder"},{"id":"user/-/label/DevOps","type":"folder"}]}`)
+
+	configPath := writeTestConfig(t, `url: https://freshrss.example.com
+username: test
+password: pass
+feeds:
+  - id: feed/22
+    days: 7
+  - id: user/-/label/Newz
+    days: 7
+  - id: Hacker Newz
+    days: 7
+  - id: user/-/state/com.google/reading-list
+    days: 30
+  - id: something/else
+    days: 7
+  - feed_title: go blog
+    days: 7
+  - category: Devop
+    days: 7
+`)
+
+	cmd := configcmd.New()
+	b := bytes.NewBufferString("")
+	cmd.SetOut(b)
+	cmd.SetErr(b)
+	cmd.SetArgs([]string{"validate", "--check-remote", "--config", configPath})
+
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Equal(t, cmdutil.ExitConfigError, cmdutil.ExitCode(err))
+	assert.Contains(t, err.Error(), "found 4 problems")
+
+	assert.NotContains(t, b.String(), `"feed/22" doesn't exist`)
+	assert.Contains(t, b.String(), configPath+`:7: feed "user/-/label/Newz" doesn't exist on the server, did you mean "user/-/label/News" (News)?`)
+	assert.Contains(t, b.String(), configPath+`:9: feed "Hacker Newz" doesn't exist on the server, did you mean "feed/22" (Hacker News)?`)
+	assert.Contains(t, b.String(), configPath+`:13: feed "something/else" doesn't exist on the server`+"\n")
+	assert.NotContains(t, b.String(), `feed_title="go blog"`)
+	assert.Contains(t, b.String(), configPath+`:17: feed category="Devop" doesn't match any feed or category on the server, did you mean "user/-/label/DevOps" (DevOps)?`)
+	assert.True(t, gock.IsDone())
+}
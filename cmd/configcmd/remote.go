@@ -0,0 +1,167 @@
+package configcmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+)
+
+// maxSuggestions is the maximum number of suggestions reported for a feed ID that doesn't exist
+const maxSuggestions = 3
+
+// stateStreams are the state streams that can be used as feed IDs, besides the ones returned by the tag list
+var stateStreams = []string{client.StateReadingList, client.StateStarred}
+
+// stream is a stream existing on the server, with a human readable title used for suggestions
+type stream struct {
+	ID    string
+	Title string
+}
+
+func (s stream) String() string {
+	if s.Title == "" || s.Title == s.ID {
+		return fmt.Sprintf("%q", s.ID)
+	}
+
+	return fmt.Sprintf("%q (%s)", s.ID, s.Title)
+}
+
+// remoteStreams lists the subscriptions, categories, labels and states of the catalog
+func remoteStreams(catalog *freshrss.Catalog) []stream {
+	seen := make(map[string]bool)
+	var streams []stream
+	add := func(id, title string) {
+		if !seen[id] {
+			seen[id] = true
+			streams = append(streams, stream{ID: id, Title: title})
+		}
+	}
+
+	for _, s := range catalog.Subscriptions {
+		add(s.ID, s.Title)
+		for _, category := range s.Categories {
+			add(category.ID, category.Label)
+		}
+	}
+
+	for _, t := range catalog.Tags {
+		add(t.ID, t.Label())
+	}
+
+	for _, id := range stateStreams {
+		add(id, "")
+	}
+
+	return streams
+}
+
+// checkFeeds reports the feeds whose ID doesn't exist in the catalog, or whose name doesn't match any of its streams,
+// suggesting the closest streams by ID or title
+func checkFeeds(feeds []config.FeedConfig, lines []int, catalog *freshrss.Catalog) []config.Problem {
+	streams := remoteStreams(catalog)
+	exists := make(map[string]bool, len(streams))
+	for _, s := range streams {
+		exists[s.ID] = true
+	}
+
+	var problems []config.Problem
+	for i, feed := range feeds {
+		var message, name string
+		switch {
+		case feed.ID != "":
+			if exists[feed.ID] {
+				continue
+			}
+			message = fmt.Sprintf("feed %q doesn't exist on the server", feed.ID)
+			name = feed.ID
+		default:
+			if _, err := catalog.Resolve(feed); err == nil {
+				continue
+			}
+			message = fmt.Sprintf("feed %s doesn't match any feed or category on the server", feed.Target())
+			name = feed.FeedTitle + feed.FeedURL + feed.Category
+		}
+
+		// Patterns are not suggested, as they are not meant to match a single stream
+		if suggestions := suggest(name, streams); name != "" && len(suggestions) > 0 {
+			names := make([]string, 0, len(suggestions))
+			for _, s := range suggestions {
+				names = append(names, s.String())
+			}
+			message += fmt.Sprintf(", did you mean %s?", strings.Join(names, " or "))
+		}
+
+		problem := config.Problem{Message: message}
+		if i < len(lines) {
+			problem.Line = lines[i]
+		}
+		problems = append(problems, problem)
+	}
+
+	return problems
+}
+
+// suggest returns the streams whose ID or title are the closest to the ID, ignoring the ones that are too different
+func suggest(id string, streams []stream) []stream {
+	type candidate struct {
+		stream   stream
+		distance int
+	}
+
+	target := strings.ToLower(id)
+	// Labels are usually mistyped by name, so they are compared with the titles as well
+	name := strings.ToLower(strings.TrimPrefix(id, client.LabelPrefix))
+	threshold := max(2, len(name)/3)
+
+	var candidates []candidate
+	for _, s := range streams {
+		distance := levenshtein(target, strings.ToLower(s.ID))
+		if s.Title != "" {
+			distance = min(distance, levenshtein(name, strings.ToLower(s.Title)))
+		}
+
+		if distance <= threshold {
+			candidates = append(candidates, candidate{stream: s, distance: distance})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	suggestions := make([]stream, 0, maxSuggestions)
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].stream)
+	}
+
+	return suggestions
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,32 @@
+package configcmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevenshtein(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, levenshtein("feed/22", "feed/22"))
+	assert.Equal(t, 1, levenshtein("feed/2", "feed/22"))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+	assert.Equal(t, 4, levenshtein("", "news"))
+}
+
+func TestSuggest(t *testing.T) {
+	t.Parallel()
+
+	streams := []stream{
+		{ID: "feed/1", Title: "Go Blog"},
+		{ID: "feed/22", Title: "Hacker News"},
+		{ID: "feed/23", Title: "Lobsters"},
+		{ID: "user/-/label/DevOps", Title: "DevOps"},
+	}
+
+	assert.Equal(t, []stream{{ID: "feed/22", Title: "Hacker News"}}, suggest("hacker news", streams))
+	assert.Equal(t, []stream{{ID: "user/-/label/DevOps", Title: "DevOps"}}, suggest("user/-/label/Devops", streams))
+	assert.Equal(t, "feed/22", suggest("feed/222", streams)[0].ID, "the closest stream is suggested first")
+	assert.Empty(t, suggest("completely/different", streams))
+}
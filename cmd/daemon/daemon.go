@@ -0,0 +1,139 @@
+// Package daemon provides the command definition for the daemon command, which runs the cleaner on schedule.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brpaz/freshrss-cleaner/cmd/cmdutil"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss"
+	freshrssclient "github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+	"github.com/brpaz/freshrss-cleaner/internal/logging"
+	"github.com/brpaz/freshrss-cleaner/internal/metrics"
+	"github.com/brpaz/freshrss-cleaner/internal/scheduler"
+)
+
+// New creates a new daemon command
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Keep running and clean up old entries from FreshRSS on schedule",
+		Long: `Keep running and clean up old entries from FreshRSS on schedule.
+
+Feeds run on the schedule defined by the --schedule flag or the "schedule" config option,
+unless they define their own "schedule". Schedules use the standard cron syntax (ex: "0 */2 * * *")
+or descriptors like "@hourly" and "@every 30m".
+
+When --metrics-address is set, Prometheus metrics are exposed on the /metrics path of that address.`,
+		RunE: runDaemon,
+	}
+
+	cmdutil.AddConfigFlag(cmd)
+	cmd.Flags().String("schedule", "", "Default cron expression for the feeds without their own schedule (overrides the config file)")
+	cmd.Flags().String("metrics-address", "", "Address to expose the Prometheus metrics on (ex: \":9090\"). Metrics are disabled when empty")
+
+	return cmd
+}
+
+// runDaemon handles the execution of the daemon command
+func runDaemon(cmd *cobra.Command, args []string) error {
+	logger := logging.FromContext(cmd.Context())
+
+	schedule, err := cmd.Flags().GetString("schedule")
+	if err != nil {
+		return fmt.Errorf("failed to get schedule flag: %w", err)
+	}
+
+	metricsAddress, err := cmd.Flags().GetString("metrics-address")
+	if err != nil {
+		return fmt.Errorf("failed to get metrics-address flag: %w", err)
+	}
+
+	// Flags are valid at this point, so there is no need to print the usage on errors
+	cmd.SilenceUsage = true
+
+	cfg, err := cmdutil.LoadValidConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	if schedule != "" {
+		cfg.Schedule = schedule
+	}
+
+	m := metrics.New()
+
+	client, err := cmdutil.NewClient(cfg, freshrssclient.WithRequestObserver(m), freshrssclient.WithLogger(logger))
+	if err != nil {
+		return err
+	}
+
+	journalOption, err := cmdutil.JournalOption(cfg, false)
+	if err != nil {
+		return err
+	}
+
+	cleaner, err := freshrss.NewCleaner(
+		freshrss.WithClient(client),
+		freshrss.WithConfig(cfg),
+		cmdutil.TokenCacheOption(cfg),
+		journalOption,
+		freshrss.WithObserver(m),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create cleaner: %w", err)
+	}
+
+	s, err := scheduler.New(
+		scheduler.WithRunner(cleaner),
+		scheduler.WithFeeds(cfg.Rules()),
+		scheduler.WithDefaultSchedule(cfg.Schedule),
+		scheduler.WithLogger(logger),
+	)
+	if err != nil {
+		return cmdutil.WithExitCode(cmdutil.ExitConfigError, err)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if metricsAddress != "" {
+		server := &http.Server{Addr: metricsAddress, Handler: metricsMux(m)}
+		go serveMetrics(ctx, logger, server)
+	}
+
+	logger.Info("Starting daemon")
+
+	return s.Run(ctx)
+}
+
+// metricsMux returns the HTTP handler serving the metrics on the /metrics path
+func metricsMux(m *metrics.Metrics) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+
+	return mux
+}
+
+// serveMetrics runs the metrics server until the context is done. Failures are only logged, so they don't stop the cleaner.
+func serveMetrics(ctx context.Context, log *slog.Logger, server *http.Server) {
+	go func() {
+		<-ctx.Done()
+		if err := server.Shutdown(context.Background()); err != nil {
+			log.Warn("Failed to shutdown metrics server", "error", err)
+		}
+	}()
+
+	log.Info("Serving metrics", "address", server.Addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Error("Metrics server failed", "error", err)
+	}
+}
@@ -0,0 +1,105 @@
+// Package explain provides the command definition for the explain command.
+package explain
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brpaz/freshrss-cleaner/cmd/cmdutil"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+)
+
+// New creates a new explain command
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain <feed>",
+		Short: "Show which rules target a feed, and which one applies to it",
+		Long: `Show which rules target a feed, and which one applies to it.
+
+The feed can be identified by its ID, title or URL. When several rules target the same feed,
+the one with the highest priority applies, then the most specific one (feed, category, pattern, all feeds),
+and then the first one defined. Rules with match or exclude filters are combined with the other rules.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runExplain,
+	}
+
+	cmdutil.AddConfigFlag(cmd)
+
+	return cmd
+}
+
+// runExplain handles the execution of the explain command
+func runExplain(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := cmdutil.LoadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	c, authToken, err := cmdutil.Login(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	catalog, err := freshrss.NewCatalog(ctx, c, authToken)
+	if err != nil {
+		return err
+	}
+
+	subscriptions := catalog.Lookup(args[0])
+	if len(subscriptions) == 0 {
+		return fmt.Errorf("feed %q not found, use the feeds list command to find the ID or title of your feeds", args[0])
+	}
+
+	rules := cfg.Rules()
+	for i, s := range subscriptions {
+		if i > 0 {
+			fmt.Fprintln(cmd.OutOrStdout())
+		}
+
+		if err := printExplanation(cmd.OutOrStdout(), s, catalog.Explain(rules, s)); err != nil {
+			return fmt.Errorf("failed to print explanation: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// printExplanation writes the feed followed by a table with the rules targeting it
+func printExplanation(out io.Writer, s client.Subscription, explanations []freshrss.Explanation) error {
+	categories := make([]string, 0, len(s.Categories))
+	for _, category := range s.Categories {
+		categories = append(categories, category.Label)
+	}
+
+	fmt.Fprintf(out, "%s %q", s.ID, s.Title)
+	if len(categories) > 0 {
+		fmt.Fprintf(out, " in %s", strings.Join(categories, ", "))
+	}
+	fmt.Fprintln(out)
+
+	if len(explanations) == 0 {
+		_, err := fmt.Fprintln(out, "No rule targets this feed, so it is not cleaned")
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TARGET\tRULE\tPRIORITY\tSTATUS\tREASON")
+	for _, e := range explanations {
+		status := "overridden"
+		if e.Applies {
+			status = "applies"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", e.Rule.Target(), e.Rule.Rule(), e.Rule.Priority, status, e.Reason)
+	}
+
+	return w.Flush()
+}
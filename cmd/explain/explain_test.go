@@ -0,0 +1,104 @@
+package explain_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/brpaz/freshrss-cleaner/cmd/explain"
+)
+
+func writeTestConfig(t *testing.T) string {
+	t.Helper()
+
+	configPath := filepath.Join(t.TempDir(), "freshrss-cleaner.yaml")
+	err := os.WriteFile(configPath, []byte(`url: https://freshrss.example.com
+username: test
+password: pass
+default:
+  older_than: 30d
+feeds:
+  - category: News
+    days: 7
+  - feed_title: Hacker News
+    days: 1
+`), 0o600)
+	require.NoError(t, err)
+
+	return configPath
+}
+
+func mockCatalog() {
+	gock.New("https://freshrss.example.com").
+		Post("/accounts/ClientLogin").
+		Reply(200).
+		BodyString("SID=test/auth-token\nLSID=null\nAuth=test/auth-token\n")
+	gock.New("https://freshrss.example.com").
+		Get("/reader/api/0/subscription/list").
+		Reply(200).
+		BodyString(`{"subscriptions":[{"id":"feed/22","title":"Hacker News","url":"https://news.ycombinator.com/rss","categories":[{"id":"user/-/label/News","label":"News"}]},{"id":"feed/7","title":"Go Blog"}]}`)
+	gock.New("https://freshrss.example.com").
+		Get("/reader/api/0/tag/list").
+		Reply(200).
+		BodyString(`{"tags":[{"id":"user/-/label/News","type":"folder"}]}`)
+}
+
+func TestExplainCmd(t *testing.T) {
+	t.Run("With feed targeted by several rules", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		mockCatalog()
+
+		cmd := explain.New()
+		b := bytes.NewBufferString("")
+		cmd.SetOut(b)
+		cmd.SetArgs([]string{"hacker news", "--config", writeTestConfig(t)})
+
+		err := cmd.Execute()
+		require.NoError(t, err)
+
+		assert.Contains(t, b.String(), `feed/22 "Hacker News" in News`)
+		assert.Contains(t, b.String(), `category="News"           days=7                   0         overridden  overridden by feed_title="Hacker News": more specific (feed > category)`)
+		assert.Contains(t, b.String(), `feed_title="Hacker News"  days=1                   0         applies     takes precedence over category="News": more specific (feed > category)`)
+		assert.Contains(t, b.String(), `default                   default, older_than=30d  0         overridden  the feed is targeted by other rules`)
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("With feed without rules", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		mockCatalog()
+
+		cmd := explain.New()
+		b := bytes.NewBufferString("")
+		cmd.SetOut(b)
+		cmd.SetArgs([]string{"feed/7", "--config", writeTestConfig(t)})
+
+		err := cmd.Execute()
+		require.NoError(t, err)
+
+		assert.Contains(t, b.String(), `feed/7 "Go Blog"`+"\n")
+		assert.Contains(t, b.String(), `default  default, older_than=30d  0         applies  no other rule targets the feed`)
+	})
+
+	t.Run("With unknown feed", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		mockCatalog()
+
+		cmd := explain.New()
+		b := bytes.NewBufferString("")
+		cmd.SetOut(b)
+		cmd.SetErr(b)
+		cmd.SetArgs([]string{"Lobsters", "--config", writeTestConfig(t)})
+
+		err := cmd.Execute()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `feed "Lobsters" not found`)
+	})
+}
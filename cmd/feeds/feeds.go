@@ -0,0 +1,129 @@
+// Package feeds provides the command definitions to inspect the feeds and categories of a FreshRSS instance.
+package feeds
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brpaz/freshrss-cleaner/cmd/cmdutil"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+)
+
+// New creates a new feeds command, with the list and categories subcommands
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "feeds",
+		Short: "Inspect the feeds and categories of your FreshRSS instance",
+	}
+
+	cmdutil.AddConfigFlag(cmd)
+
+	cmd.AddCommand(newListCmd())
+	cmd.AddCommand(newCategoriesCmd())
+
+	return cmd
+}
+
+// newListCmd creates the command that lists the subscribed feeds
+func newListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the subscribed feeds, with their IDs, categories and unread counts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, authToken, err := login(cmd)
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+			subscriptions, err := c.SubscriptionList(ctx, authToken)
+			if err != nil {
+				return fmt.Errorf("failed to list subscriptions: %w", err)
+			}
+
+			counts, err := c.UnreadCounts(ctx, authToken)
+			if err != nil {
+				return fmt.Errorf("failed to get unread counts: %w", err)
+			}
+
+			return printFeeds(cmd.OutOrStdout(), subscriptions, counts)
+		},
+	}
+}
+
+// newCategoriesCmd creates the command that lists the categories
+func newCategoriesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "categories",
+		Short: "List the categories and labels, with their IDs and unread counts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, authToken, err := login(cmd)
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+			tags, err := c.TagList(ctx, authToken)
+			if err != nil {
+				return fmt.Errorf("failed to list tags: %w", err)
+			}
+
+			counts, err := c.UnreadCounts(ctx, authToken)
+			if err != nil {
+				return fmt.Errorf("failed to get unread counts: %w", err)
+			}
+
+			return printCategories(cmd.OutOrStdout(), tags, counts)
+		},
+	}
+}
+
+// login loads the configuration and returns an authenticated FreshRSS client
+func login(cmd *cobra.Command) (*client.Client, string, error) {
+	cfg, err := cmdutil.LoadConfig(cmd)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return cmdutil.Login(cmd.Context(), cfg)
+}
+
+// printFeeds writes the subscriptions as a table, sorted by title
+func printFeeds(out io.Writer, subscriptions []client.Subscription, counts map[string]int) error {
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return strings.ToLower(subscriptions[i].Title) < strings.ToLower(subscriptions[j].Title)
+	})
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTITLE\tCATEGORY\tUNREAD")
+	for _, s := range subscriptions {
+		categories := make([]string, 0, len(s.Categories))
+		for _, category := range s.Categories {
+			categories = append(categories, category.Label)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", s.ID, s.Title, strings.Join(categories, ", "), counts[s.ID])
+	}
+
+	return w.Flush()
+}
+
+// printCategories writes the labels as a table, sorted by ID
+func printCategories(out io.Writer, tags []client.Tag, counts map[string]int) error {
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].ID < tags[j].ID
+	})
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tUNREAD")
+	for _, t := range tags {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", t.ID, t.Label(), counts[t.ID])
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,79 @@
+package feeds_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/brpaz/freshrss-cleaner/cmd/feeds"
+)
+
+func writeTestConfig(t *testing.T) string {
+	t.Helper()
+
+	configPath := filepath.Join(t.TempDir(), "freshrss-cleaner.yaml")
+	err := os.WriteFile(configPath, []byte("url: https://freshrss.example.com\nusername: test\npassword: pass\n"), 0o600)
+	require.NoError(t, err)
+
+	return configPath
+}
+
+func mockLogin() {
+	gock.New("https://freshrss.example.com").
+		Post("/accounts/ClientLogin").
+		Reply(200).
+		BodyString("SID=test/auth-token\nLSID=null\nAuth=test/auth-token\n")
+
+	gock.New("https://freshrss.example.com").
+		Get("/reader/api/0/unread-count").
+		Reply(200).
+		BodyString(`{"unreadcounts":[{"id":"feed/22","count":40},{"id":"user/-/label/News","count":40}]}`)
+}
+
+func TestFeedsListCmd(t *testing.T) {
+	defer gock.Off() // Flush pending mocks after test execution
+
+	mockLogin()
+	gock.New("https://freshrss.example.com").
+		Get("/reader/api/0/subscription/list").
+		Reply(200).
+		BodyString(`{"subscriptions":[{"id":"feed/22","title":"Example News","categories":[{"id":"user/-/label/News","label":"News"}]}]}`)
+
+	cmd := feeds.New()
+	b := bytes.NewBufferString("")
+	cmd.SetOut(b)
+	cmd.SetArgs([]string{"list", "--config", writeTestConfig(t)})
+
+	err := cmd.Execute()
+	require.NoError(t, err)
+
+	assert.Contains(t, b.String(), "ID       TITLE         CATEGORY  UNREAD")
+	assert.Contains(t, b.String(), "feed/22  Example News  News      40")
+	assert.True(t, gock.IsDone())
+}
+
+func TestFeedsCategoriesCmd(t *testing.T) {
+	defer gock.Off() // Flush pending mocks after test execution
+
+	mockLogin()
+	gock.New("https://freshrss.example.com").
+		Get("/reader/api/0/tag/list").
+		Reply(200).
+		BodyString(`{"tags":[{"id":"user/-/label/News","type":"folder"}]}`)
+
+	cmd := feeds.New()
+	b := bytes.NewBufferString("")
+	cmd.SetOut(b)
+	cmd.SetArgs([]string{"categories", "--config", writeTestConfig(t)})
+
+	err := cmd.Execute()
+	require.NoError(t, err)
+
+	assert.Contains(t, b.String(), "user/-/label/News  News  40")
+	assert.True(t, gock.IsDone())
+}
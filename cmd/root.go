@@ -2,24 +2,105 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"os"
+
 	"github.com/spf13/cobra"
 
 	"github.com/brpaz/freshrss-cleaner/cmd/clean"
+	"github.com/brpaz/freshrss-cleaner/cmd/configcmd"
 	"github.com/brpaz/freshrss-cleaner/cmd/createconfig"
+	"github.com/brpaz/freshrss-cleaner/cmd/daemon"
+	"github.com/brpaz/freshrss-cleaner/cmd/explain"
+	"github.com/brpaz/freshrss-cleaner/cmd/feeds"
+	"github.com/brpaz/freshrss-cleaner/cmd/undo"
 	"github.com/brpaz/freshrss-cleaner/cmd/version"
+	"github.com/brpaz/freshrss-cleaner/internal/logging"
 )
 
 // NewRootCmd returns a new instance of the root command for the application
 func NewRootCmd() *cobra.Command {
+	// logFile is the file the logs are written to, when set with the log-file flag, closed once the command finishes
+	var logFile io.Closer
+
 	rootCmd := &cobra.Command{
 		Use:   "freshrss-cleaner",
 		Short: "A command line tool to clean up old entries from FreshRSS",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			logFile, err = setupLogger(cmd)
+			return err
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			if logFile == nil {
+				return nil
+			}
+			return logFile.Close()
+		},
 	}
 
+	rootCmd.PersistentFlags().String("log-format", logging.FormatText, "Format of the logs: text or json")
+	rootCmd.PersistentFlags().String("log-level", "info", "Minimum level of the logs: debug, info, warn or error. The debug level logs every API request, without credentials")
+	rootCmd.PersistentFlags().String("log-file", "", "Path of a file to append the logs to, instead of writing them to the standard error")
+
 	// Reggister subcommands
 	rootCmd.AddCommand(version.New())
 	rootCmd.AddCommand(clean.New())
 	rootCmd.AddCommand(createconfig.New())
+	rootCmd.AddCommand(configcmd.New())
+	rootCmd.AddCommand(feeds.New())
+	rootCmd.AddCommand(daemon.New())
+	rootCmd.AddCommand(explain.New())
+	rootCmd.AddCommand(undo.New())
 
 	return rootCmd
 }
+
+// setupLogger creates the logger configured by the log flags, and passes it to the command through its context.
+// It returns the log file to close once the command finishes, if any.
+func setupLogger(cmd *cobra.Command) (io.Closer, error) {
+	format, err := cmd.Flags().GetString("log-format")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get log-format flag: %w", err)
+	}
+
+	level, err := cmd.Flags().GetString("log-level")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get log-level flag: %w", err)
+	}
+
+	path, err := cmd.Flags().GetString("log-file")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get log-file flag: %w", err)
+	}
+
+	// Logs are kept apart from the output of the commands, like the run summary, which is written to the standard output
+	var (
+		out  io.Writer = cmd.ErrOrStderr()
+		file *os.File
+	)
+	if path != "" {
+		file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		out = file
+	}
+
+	logger, err := logging.New(out, format, level)
+	if err != nil {
+		if file != nil {
+			file.Close()
+		}
+		return nil, err
+	}
+
+	cmd.SetContext(logging.NewContext(cmd.Context(), logger))
+
+	if file == nil {
+		return nil, nil
+	}
+
+	return file, nil
+}
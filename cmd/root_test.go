@@ -0,0 +1,60 @@
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/brpaz/freshrss-cleaner/cmd"
+)
+
+func TestRootCmd_JSONLogs(t *testing.T) {
+	defer gock.Off() // Flush pending mocks after test execution
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	configPath := filepath.Join(t.TempDir(), "freshrss-cleaner.yaml")
+	err := os.WriteFile(configPath, []byte("url: https://freshrss.example.com\nusername: test\npassword: pass\nfeeds:\n  - id: feed/1\n    days: 7\n"), 0o600)
+	require.NoError(t, err)
+
+	gock.New("https://freshrss.example.com").
+		Post("/accounts/ClientLogin").
+		Reply(200).
+		BodyString("SID=test/auth-token\nLSID=null\nAuth=test/auth-token\n")
+	gock.New("https://freshrss.example.com").
+		Get("/reader/api/0/unread-count").
+		Reply(200).
+		BodyString(`{"unreadcounts":[{"id":"feed/1","count":1}]}`)
+	gock.New("https://freshrss.example.com").
+		Get("/reader/api/0/stream/contents/feed/1").
+		Reply(200).
+		BodyString(`{"items":[{"id":"item1","title":"Old item","published":1700000000}]}`)
+
+	rootCmd := cmd.NewRootCmd()
+	stdout := bytes.NewBufferString("")
+	stderr := bytes.NewBufferString("")
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	rootCmd.SetArgs([]string{"clean", "--dry-run", "--log-format", "json", "--config", configPath})
+
+	err = rootCmd.Execute()
+	require.NoError(t, err)
+
+	assert.Contains(t, stdout.String(), "FEED    RULE")
+	assert.NotContains(t, stdout.String(), `"level"`, "the logs must not be mixed with the run summary")
+
+	lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	require.NotEmpty(t, lines)
+	for _, line := range lines {
+		assert.True(t, json.Valid([]byte(line)), "log line is not JSON: %s", line)
+	}
+	assert.Contains(t, stderr.String(), `"msg":"Would mark as read"`)
+}
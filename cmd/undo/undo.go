@@ -0,0 +1,146 @@
+// Package undo provides the command definition for the undo command.
+package undo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brpaz/freshrss-cleaner/cmd/cmdutil"
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+	"github.com/brpaz/freshrss-cleaner/internal/journal"
+)
+
+// New creates a new undo command
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "undo [run-id]",
+		Short: "Mark the items marked as read by a run as unread again",
+		Long: `Mark the items marked as read by a run as unread again, using the journal recorded by the clean and daemon commands.
+
+Without a run ID, the last run is undone. Use --list to show the runs recorded in the journal.
+Items read by yourself after the run are marked as unread too.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runUndo,
+	}
+
+	cmdutil.AddConfigFlag(cmd)
+	cmd.Flags().Bool("list", false, "List the runs recorded in the journal, newest first")
+
+	return cmd
+}
+
+// runUndo handles the execution of the undo command
+func runUndo(cmd *cobra.Command, args []string) error {
+	list, err := cmd.Flags().GetBool("list")
+	if err != nil {
+		return fmt.Errorf("failed to get list flag: %w", err)
+	}
+
+	cmd.SilenceUsage = true
+
+	cfg, err := cmdutil.LoadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	j, err := cmdutil.NewJournal(cfg)
+	if err != nil {
+		return err
+	}
+
+	runs, err := j.Runs()
+	if err != nil {
+		return err
+	}
+
+	if list {
+		return printRuns(cmd.OutOrStdout(), runs)
+	}
+
+	runID, err := runToUndo(j, runs, args)
+	if err != nil {
+		return err
+	}
+
+	count, err := undoRun(cmd.Context(), cfg, j, runID)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(cmd.OutOrStdout(), "%d items of run %s marked as unread\n", count, runID)
+
+	return err
+}
+
+// runToUndo returns the run ID given as argument, or the last run recorded in the journal
+func runToUndo(j *journal.FileJournal, runs []journal.Run, args []string) (string, error) {
+	switch {
+	case len(args) > 0:
+		return args[0], nil
+	case len(runs) > 0:
+		return runs[0].ID, nil
+	default:
+		return "", fmt.Errorf("no runs recorded in the journal %s", j.Dir())
+	}
+}
+
+// undoRun marks the items of the run as unread again, and removes the run from the journal. It returns the number of items marked as unread.
+func undoRun(ctx context.Context, cfg *config.RootConfig, j *journal.FileJournal, runID string) (int, error) {
+	entries, err := j.Entries(runID)
+	if errors.Is(err, journal.ErrRunNotFound) {
+		return 0, fmt.Errorf("%w, use --list to show the recorded runs", err)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	ids := itemIDs(entries)
+
+	c, authToken, err := cmdutil.Login(ctx, cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := c.EditTag(ctx, authToken, ids, nil, []string{client.StateRead}); err != nil {
+		return 0, fmt.Errorf("failed to mark items as unread: %w", err)
+	}
+
+	// The run can't be undone twice, as it would mark as unread the items read since then
+	if err := j.Remove(runID); err != nil {
+		return 0, err
+	}
+
+	return len(ids), nil
+}
+
+// itemIDs returns the IDs of the items of the entries, without duplicates
+func itemIDs(entries []journal.Entry) []string {
+	seen := make(map[string]bool, len(entries))
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !seen[entry.ItemID] {
+			seen[entry.ItemID] = true
+			ids = append(ids, entry.ItemID)
+		}
+	}
+
+	return ids
+}
+
+// printRuns writes the runs as a table
+func printRuns(out io.Writer, runs []journal.Run) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN ID\tTIME\tFEEDS\tITEMS")
+	for _, run := range runs {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", run.ID, run.Time.Local().Format(time.DateTime), run.Feeds, run.Items)
+	}
+
+	return w.Flush()
+}
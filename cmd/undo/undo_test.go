@@ -0,0 +1,114 @@
+package undo_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/brpaz/freshrss-cleaner/cmd/undo"
+	"github.com/brpaz/freshrss-cleaner/internal/journal"
+)
+
+// setupJournal writes a config file and points the journal to a temporary data directory, returning the config path and the journal
+func setupJournal(t *testing.T) (string, *journal.FileJournal) {
+	t.Helper()
+
+	dataDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataDir)
+
+	configPath := filepath.Join(t.TempDir(), "freshrss-cleaner.yaml")
+	err := os.WriteFile(configPath, []byte("url: https://freshrss.example.com\nusername: test\npassword: pass\nfeeds:\n  - id: feed/1\n    days: 7\n"), 0o600)
+	require.NoError(t, err)
+
+	return configPath, journal.New(filepath.Join(dataDir, "freshrss-cleaner"), "https://freshrss.example.com", "test", 0)
+}
+
+func TestUndoCmd(t *testing.T) {
+	t.Run("Undoes the last run", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		configPath, j := setupJournal(t)
+		require.NoError(t, j.Record("20240101-000000-0000", "feed/1", []string{"old"}))
+		require.NoError(t, j.Record("20240102-000000-0000", "feed/1", []string{"item1", "item2"}))
+		require.NoError(t, j.Record("20240102-000000-0000", "user/-/label/News", []string{"item1"}))
+
+		gock.New("https://freshrss.example.com").
+			Post("/accounts/ClientLogin").
+			Reply(200).
+			BodyString("SID=test/auth-token\nLSID=null\nAuth=test/auth-token\n")
+		gock.New("https://freshrss.example.com").
+			Get("/reader/api/0/token").
+			Reply(200).
+			BodyString("test-action-token\n")
+		gock.New("https://freshrss.example.com").
+			Post("/reader/api/0/edit-tag").
+			MatchType("url").
+			BodyString(`^T=test-action-token&i=item1&i=item2&r=user%2F-%2Fstate%2Fcom.google%2Fread$`).
+			Reply(200).
+			BodyString("OK")
+
+		cmd := undo.New()
+		b := bytes.NewBufferString("")
+		cmd.SetOut(b)
+		cmd.SetArgs([]string{"--config", configPath})
+
+		err := cmd.Execute()
+		require.NoError(t, err)
+		assert.Equal(t, "2 items of run 20240102-000000-0000 marked as unread\n", b.String())
+		assert.True(t, gock.IsDone())
+
+		runs, err := j.Runs()
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, "20240101-000000-0000", runs[0].ID)
+	})
+
+	t.Run("Lists the runs", func(t *testing.T) {
+		configPath, j := setupJournal(t)
+		require.NoError(t, j.Record("20240102-000000-0000", "feed/1", []string{"item1", "item2"}))
+
+		cmd := undo.New()
+		b := bytes.NewBufferString("")
+		cmd.SetOut(b)
+		cmd.SetArgs([]string{"--list", "--config", configPath})
+
+		err := cmd.Execute()
+		require.NoError(t, err)
+		assert.Contains(t, b.String(), "RUN ID                TIME")
+		assert.Contains(t, b.String(), "20240102-000000-0000  ")
+		assert.Contains(t, b.String(), "  1      2\n")
+	})
+
+	t.Run("With unknown run", func(t *testing.T) {
+		configPath, _ := setupJournal(t)
+
+		cmd := undo.New()
+		b := bytes.NewBufferString("")
+		cmd.SetOut(b)
+		cmd.SetErr(b)
+		cmd.SetArgs([]string{"20240102-000000-0000", "--config", configPath})
+
+		err := cmd.Execute()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, journal.ErrRunNotFound)
+	})
+
+	t.Run("Without runs", func(t *testing.T) {
+		configPath, _ := setupJournal(t)
+
+		cmd := undo.New()
+		b := bytes.NewBufferString("")
+		cmd.SetOut(b)
+		cmd.SetErr(b)
+		cmd.SetArgs([]string{"--config", configPath})
+
+		err := cmd.Execute()
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "no runs recorded in the journal")
+	})
+}
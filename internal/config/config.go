@@ -5,30 +5,286 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 // RootConfig represents the root configuration structure for the application.
 type RootConfig struct {
-	URL      string       `yaml:"url"`
-	Username string       `yaml:"username"`
-	Password string       `yaml:"password"`
-	Feeds    []FeedConfig `yaml:"feeds"`
+	URL           string        `yaml:"url"`
+	Username      string        `yaml:"username"`
+	Password      string        `yaml:"password"`
+	AuthToken     string        `yaml:"auth_token"`
+	FailurePolicy FailurePolicy `yaml:"failure_policy"`
+	Schedule      string        `yaml:"schedule"`
+	Concurrency   int           `yaml:"concurrency"`
+	RateLimit     float64       `yaml:"rate_limit"`
+	Retry         RetryConfig   `yaml:"retry"`
+	Protect       ProtectConfig `yaml:"protect"`
+	Journal       JournalConfig `yaml:"journal"`
+	// Default is the rule applied to every subscription not targeted by any of the feeds
+	Default *FeedConfig  `yaml:"default"`
+	Feeds   []FeedConfig `yaml:"feeds"`
+}
+
+// Rules returns the feeds to be cleaned, followed by the default rule when configured.
+// Each rule knows its position, used to break ties between rules targeting the same feed.
+func (c *RootConfig) Rules() []FeedConfig {
+	rules := make([]FeedConfig, 0, len(c.Feeds)+1)
+	rules = append(rules, c.Feeds...)
+
+	if c.Default != nil {
+		fallback := *c.Default
+		fallback.fallback = true
+		rules = append(rules, fallback)
+	}
+
+	for i := range rules {
+		rules[i].position = i + 1
+	}
+
+	return rules
+}
+
+// FailurePolicy defines how the cleaner handles failures processing individual feeds
+type FailurePolicy string
+
+const (
+	// FailurePolicyContinue processes all the feeds and ignores failures
+	FailurePolicyContinue FailurePolicy = "continue"
+	// FailurePolicyFailFast stops processing the remaining feeds on the first failure
+	FailurePolicyFailFast FailurePolicy = "fail-fast"
+	// FailurePolicyFailAtEnd processes all the feeds and reports a failure at the end if any feed failed. This is the default.
+	FailurePolicyFailAtEnd FailurePolicy = "fail-at-end"
+)
+
+// Validate checks if the failure policy is one of the supported values. An empty policy is valid and means the default.
+func (p FailurePolicy) Validate() error {
+	switch p {
+	case "", FailurePolicyContinue, FailurePolicyFailFast, FailurePolicyFailAtEnd:
+		return nil
+	default:
+		return fmt.Errorf("invalid failure policy %q: must be one of %s, %s or %s", p, FailurePolicyContinue, FailurePolicyFailFast, FailurePolicyFailAtEnd)
+	}
+}
+
+// OrDefault returns the policy, or FailurePolicyFailAtEnd when not set
+func (p FailurePolicy) OrDefault() FailurePolicy {
+	if p == "" {
+		return FailurePolicyFailAtEnd
+	}
+
+	return p
+}
+
+// RetryConfig defines how the requests to the FreshRSS API failing with transient errors are retried.
+// Fields that are not set use the defaults of the client.
+type RetryConfig struct {
+	// MaxAttempts is the maximum number of attempts of a request, including the first one. 1 disables the retries.
+	MaxAttempts int `yaml:"max_attempts"`
+	// InitialBackoff is the time waited before the first retry, doubled on each subsequent retry
+	InitialBackoff Duration `yaml:"initial_backoff"`
+	// MaxBackoff is the maximum time waited between attempts
+	MaxBackoff Duration `yaml:"max_backoff"`
+	// Jitter is the fraction of the backoff randomly added or removed (ex: 0.2 for ±20%)
+	Jitter *float64 `yaml:"jitter"`
+}
+
+// ProtectConfig defines the items that must never be marked as read by the cleaner.
+type ProtectConfig struct {
+	// Starred protects the starred items. Enabled by default.
+	Starred *bool `yaml:"starred"`
+	// Labels protects the items having any of these labels, either by name (ex: "Later") or stream ID (ex: "user/-/label/Later").
+	Labels []string `yaml:"labels"`
+}
+
+// StarredEnabled checks if starred items are protected, which is the default when not configured
+func (p ProtectConfig) StarredEnabled() bool {
+	return p.Starred == nil || *p.Starred
+}
+
+// JournalConfig defines the journal of the items marked as read, used to undo the cleaner runs
+type JournalConfig struct {
+	// Enabled records the items marked as read by every run. Enabled by default.
+	Enabled *bool `yaml:"enabled"`
+	// KeepRuns is the number of runs kept in the journal, the oldest ones are removed. Defaults to 50.
+	KeepRuns int `yaml:"keep_runs"`
+}
+
+// IsEnabled checks if the journal is enabled, which is the default when not configured
+func (j JournalConfig) IsEnabled() bool {
+	return j.Enabled == nil || *j.Enabled
 }
 
 // FeedConfig represents the configuration for a specific feed.
+// The feed, category or label is identified by exactly one of ID, FeedTitle, FeedURL or Category.
+// Alternatively, the rule can target many feeds with FeedsMatching, CategoryMatching or AllFeeds.
 type FeedConfig struct {
-	ID   string `yaml:"id"`
-	Days int    `yaml:"days"`
+	ID string `yaml:"id"`
+	// FeedTitle, FeedURL and Category identify the targets by name, and are resolved to their IDs when the cleaner runs
+	FeedTitle string `yaml:"feed_title"`
+	FeedURL   string `yaml:"feed_url"`
+	Category  string `yaml:"category"`
+	// FeedsMatching, CategoryMatching and AllFeeds target every subscription with a title or category matching a glob pattern
+	// (ex: "*news*"), or all of them, except the ones matching ExcludeFeeds or ExcludeCategories. They are expanded when the cleaner runs.
+	FeedsMatching     string   `yaml:"feeds_matching"`
+	CategoryMatching  string   `yaml:"category_matching"`
+	AllFeeds          bool     `yaml:"all_feeds"`
+	ExcludeFeeds      []string `yaml:"exclude_feeds"`
+	ExcludeCategories []string `yaml:"exclude_categories"`
+	// Skip opts the targets out of the default rule, without cleaning them
+	Skip bool `yaml:"skip"`
+	// Priority decides which rule applies to the feeds targeted by several rules, before their specificity
+	Priority int `yaml:"priority"`
+
+	Days       int         `yaml:"days"`
+	OlderThan  Duration    `yaml:"older_than"`
+	KeepUnread int         `yaml:"keep_unread"`
+	Match      *ItemFilter `yaml:"match"`
+	Exclude    *ItemFilter `yaml:"exclude"`
+	Schedule   string      `yaml:"schedule"`
+
+	// fallback is set on the default rule returned by RootConfig.Rules
+	fallback bool
+	// position is the position of the rule returned by RootConfig.Rules, starting at 1
+	position int
+}
+
+// IsDefault checks if this is the default rule, applied to every subscription not targeted by any other rule
+func (f FeedConfig) IsDefault() bool {
+	return f.fallback
+}
+
+// Position returns the position of the rule in the configuration, starting at 1, or 0 when it wasn't returned by RootConfig.Rules
+func (f FeedConfig) Position() int {
+	return f.position
+}
+
+// Target returns a short description of the feed, category or label targeted by the rule:
+// its ID, or how it is identified by name (ex: feed_title="Hacker News")
+func (f FeedConfig) Target() string {
+	switch {
+	case f.fallback:
+		return "default"
+	case f.ID != "":
+		return f.ID
+	case f.FeedTitle != "":
+		return fmt.Sprintf("feed_title=%q", f.FeedTitle)
+	case f.FeedURL != "":
+		return fmt.Sprintf("feed_url=%q", f.FeedURL)
+	case f.Category != "":
+		return fmt.Sprintf("category=%q", f.Category)
+	case f.FeedsMatching != "":
+		return fmt.Sprintf("feeds_matching=%q", f.FeedsMatching)
+	case f.CategoryMatching != "":
+		return fmt.Sprintf("category_matching=%q", f.CategoryMatching)
+	case f.AllFeeds:
+		return "all_feeds"
+	default:
+		return ""
+	}
+}
+
+// IsPattern checks if the rule targets many feeds, with FeedsMatching, CategoryMatching or AllFeeds
+func (f FeedConfig) IsPattern() bool {
+	return f.FeedsMatching != "" || f.CategoryMatching != "" || f.AllFeeds
+}
+
+// targets returns the number of fields identifying the target of the rule, which must be exactly one
+func (f FeedConfig) targets() int {
+	count := 0
+	for _, target := range []string{f.ID, f.FeedTitle, f.FeedURL, f.Category, f.FeedsMatching, f.CategoryMatching} {
+		if target != "" {
+			count++
+		}
+	}
+
+	if f.AllFeeds {
+		count++
+	}
+
+	return count
+}
+
+// hasRule checks if the feed defines any rule restricting the items to mark as read
+func (f FeedConfig) hasRule() bool {
+	return f.HasMaxAge() || f.KeepUnread != 0 || f.HasFilters()
+}
+
+// Rule returns a short description of the rule applied to the feed (ex: "older_than=7d, keep_unread=50").
+// The default rule is prefixed with "default", and rules skipping the feed are described as "skip".
+func (f FeedConfig) Rule() string {
+	if f.Skip {
+		return "skip"
+	}
+
+	var parts []string
+
+	switch {
+	case f.OlderThan > 0:
+		parts = append(parts, "older_than="+f.OlderThan.String())
+	case f.Days > 0:
+		parts = append(parts, fmt.Sprintf("days=%d", f.Days))
+	}
+
+	if f.KeepUnread > 0 {
+		parts = append(parts, fmt.Sprintf("keep_unread=%d", f.KeepUnread))
+	}
+
+	if f.Match != nil {
+		parts = append(parts, "match")
+	}
+
+	if f.Exclude != nil {
+		parts = append(parts, "exclude")
+	}
+
+	if len(parts) == 0 {
+		parts = append(parts, "all")
+	}
+
+	if f.fallback {
+		parts = append([]string{"default"}, parts...)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// ItemFilter defines regular expressions matched against the fields of the items of a feed. Empty fields are ignored.
+type ItemFilter struct {
+	Title   string `yaml:"title"`
+	Author  string `yaml:"author"`
+	URL     string `yaml:"url"`
+	Content string `yaml:"content"`
+}
+
+// HasFilters checks if the feed restricts the items to mark as read with match or exclude rules
+func (f FeedConfig) HasFilters() bool {
+	return f.Match != nil || f.Exclude != nil
+}
+
+// HasMaxAge checks if the feed defines a maximum age for its unread items, with either Days or OlderThan
+func (f FeedConfig) HasMaxAge() bool {
+	return f.Days > 0 || f.OlderThan > 0
+}
+
+// Cutoff returns the time before which the items of the feed are considered old.
+// When both are set, OlderThan takes precedence over Days.
+func (f FeedConfig) Cutoff(now time.Time) time.Time {
+	if f.OlderThan > 0 {
+		return now.Add(-time.Duration(f.OlderThan))
+	}
+
+	return now.AddDate(0, 0, -f.Days)
 }
 
 // DefaultConfig provides the default configuration template
 const DefaultConfig = `url: "https://<your-freshrss-instance>"
 username: "user"
 password: "pass"
-api_key: ""
 feeds:
   - id: "feed1"
-	days: 7
+    days: 7
 `
 
 // DefaultConfigFilePath returns the default path for the configuration file.
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -55,3 +56,119 @@ func TestCreateDefaultConfigFile(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestFeedConfig_Cutoff(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 4, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("WithDays", func(t *testing.T) {
+		t.Parallel()
+		feed := config.FeedConfig{ID: "feed/1", Days: 7}
+		assert.Equal(t, time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC), feed.Cutoff(now))
+	})
+
+	t.Run("WithOlderThan", func(t *testing.T) {
+		t.Parallel()
+		feed := config.FeedConfig{ID: "feed/1", OlderThan: config.Duration(36 * time.Hour)}
+		assert.Equal(t, time.Date(2025, 4, 9, 0, 0, 0, 0, time.UTC), feed.Cutoff(now))
+	})
+
+	t.Run("OlderThanTakesPrecedenceOverDays", func(t *testing.T) {
+		t.Parallel()
+		feed := config.FeedConfig{ID: "feed/1", Days: 7, OlderThan: config.Duration(6 * time.Hour)}
+		assert.Equal(t, time.Date(2025, 4, 10, 6, 0, 0, 0, time.UTC), feed.Cutoff(now))
+	})
+}
+
+func TestFeedConfig_HasMaxAge(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, config.FeedConfig{Days: 7}.HasMaxAge())
+	assert.True(t, config.FeedConfig{OlderThan: config.Duration(time.Hour)}.HasMaxAge())
+	assert.False(t, config.FeedConfig{KeepUnread: 50}.HasMaxAge())
+}
+
+func TestProtectConfig_StarredEnabled(t *testing.T) {
+	t.Parallel()
+
+	enabled, disabled := true, false
+
+	assert.True(t, config.ProtectConfig{}.StarredEnabled())
+	assert.True(t, config.ProtectConfig{Starred: &enabled}.StarredEnabled())
+	assert.False(t, config.ProtectConfig{Starred: &disabled}.StarredEnabled())
+}
+
+func TestJournalConfig_IsEnabled(t *testing.T) {
+	t.Parallel()
+
+	enabled, disabled := true, false
+
+	assert.True(t, config.JournalConfig{}.IsEnabled())
+	assert.True(t, config.JournalConfig{Enabled: &enabled}.IsEnabled())
+	assert.False(t, config.JournalConfig{Enabled: &disabled}.IsEnabled())
+}
+
+func TestFeedConfig_Rule(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "all", config.FeedConfig{}.Rule())
+	assert.Equal(t, "skip", config.FeedConfig{Skip: true}.Rule())
+	assert.Equal(t, "days=7", config.FeedConfig{Days: 7}.Rule())
+	assert.Equal(t, "older_than=36h0m0s, keep_unread=50", config.FeedConfig{
+		Days:       7,
+		OlderThan:  config.Duration(36 * time.Hour),
+		KeepUnread: 50,
+	}.Rule())
+	assert.Equal(t, "older_than=2w, match, exclude", config.FeedConfig{
+		OlderThan: config.Duration(14 * 24 * time.Hour),
+		Match:     &config.ItemFilter{Title: "^Sponsored"},
+		Exclude:   &config.ItemFilter{Author: "Jane"},
+	}.Rule())
+}
+
+func TestFeedConfig_Target(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "feed/22", config.FeedConfig{ID: "feed/22"}.Target())
+	assert.Equal(t, `feed_title="Hacker News"`, config.FeedConfig{FeedTitle: "Hacker News"}.Target())
+	assert.Equal(t, `feed_url="https://go.dev/blog/feed.atom"`, config.FeedConfig{FeedURL: "https://go.dev/blog/feed.atom"}.Target())
+	assert.Equal(t, `category="DevOps"`, config.FeedConfig{Category: "DevOps"}.Target())
+}
+
+func TestRootConfig_Rules(t *testing.T) {
+	t.Parallel()
+
+	feeds := []config.FeedConfig{{ID: "feed/22", Days: 7}, {Category: "News", Skip: true}}
+
+	cfg := config.RootConfig{Feeds: feeds}
+	rules := cfg.Rules()
+	require.Len(t, rules, 2)
+	assert.Equal(t, "feed/22", rules[0].Target())
+	assert.Equal(t, 1, rules[0].Position())
+	assert.Equal(t, 2, rules[1].Position())
+	assert.Equal(t, 0, feeds[0].Position(), "the configured feeds must not be modified")
+
+	cfg.Default = &config.FeedConfig{OlderThan: config.Duration(30 * 24 * time.Hour)}
+	rules = cfg.Rules()
+	require.Len(t, rules, 3)
+	assert.False(t, rules[0].IsDefault())
+	assert.Equal(t, 3, rules[2].Position())
+	assert.True(t, rules[2].IsDefault())
+	assert.Equal(t, "default", rules[2].Target())
+	assert.Equal(t, "default, older_than=30d", rules[2].Rule())
+	assert.False(t, cfg.Default.IsDefault(), "the configured default rule must not be modified")
+}
+
+func TestFailurePolicy(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, config.FailurePolicy("").Validate())
+	assert.NoError(t, config.FailurePolicyContinue.Validate())
+	assert.NoError(t, config.FailurePolicyFailFast.Validate())
+	assert.NoError(t, config.FailurePolicyFailAtEnd.Validate())
+	assert.ErrorContains(t, config.FailurePolicy("retry").Validate(), `invalid failure policy "retry"`)
+
+	assert.Equal(t, config.FailurePolicyFailAtEnd, config.FailurePolicy("").OrDefault())
+	assert.Equal(t, config.FailurePolicyContinue, config.FailurePolicyContinue.OrDefault())
+}
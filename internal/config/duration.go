@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Day, Week and Month define the extra units supported by ParseDuration on top of the ones supported by time.ParseDuration.
+// A month is always considered to have 30 days.
+const (
+	Day   = 24 * time.Hour
+	Week  = 7 * Day
+	Month = 30 * Day
+)
+
+// durationRegex matches durations expressed in days, weeks or months (ex: 3d, 2w, 1mo)
+var durationRegex = regexp.MustCompile(`^(\d+)(d|w|mo)$`)
+
+// Duration is a time.Duration that can be unmarshalled from human friendly strings like "36h", "3d", "2w" or "1mo"
+type Duration time.Duration
+
+// ParseDuration parses a human friendly duration string.
+// Besides the units supported by time.ParseDuration, it accepts "d" (days), "w" (weeks) and "mo" (months of 30 days).
+func ParseDuration(s string) (time.Duration, error) {
+	if matches := durationRegex.FindStringSubmatch(s); matches != nil {
+		value, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+
+		units := map[string]time.Duration{"d": Day, "w": Week, "mo": Month}
+		return time.Duration(value) * units[matches[2]], nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: expected a value like 36h, 3d, 2w or 1mo", s)
+	}
+
+	return d, nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+
+	// Reported as a type error, like the other invalid values, so the rest of the file is still decoded and validated
+	parsed, err := ParseDuration(s)
+	if err != nil {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: %v", value.Line, err)}}
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// String returns the string representation of the duration, using days or weeks when possible (ex: "3d", "2w")
+func (d Duration) String() string {
+	switch {
+	case d > 0 && time.Duration(d)%Week == 0:
+		return fmt.Sprintf("%dw", time.Duration(d)/Week)
+	case d > 0 && time.Duration(d)%Day == 0:
+		return fmt.Sprintf("%dd", time.Duration(d)/Day)
+	default:
+		return time.Duration(d).String()
+	}
+}
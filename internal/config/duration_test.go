@@ -0,0 +1,67 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+)
+
+func TestParseDuration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{input: "36h", expected: 36 * time.Hour},
+		{input: "90m", expected: 90 * time.Minute},
+		{input: "1h30m", expected: 90 * time.Minute},
+		{input: "3d", expected: 3 * 24 * time.Hour},
+		{input: "2w", expected: 14 * 24 * time.Hour},
+		{input: "1mo", expected: 30 * 24 * time.Hour},
+	}
+
+	for _, tc := range tests {
+		tc := tc // capture range variable
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+
+			d, err := config.ParseDuration(tc.input)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, d)
+		})
+	}
+
+	t.Run("WithInvalidDuration", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := config.ParseDuration("3 days")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid duration "3 days"`)
+	})
+}
+
+func TestDuration_UnmarshalYAML(t *testing.T) {
+	t.Parallel()
+
+	var feed config.FeedConfig
+	err := yaml.Unmarshal([]byte("id: feed/1\nolder_than: 2w\n"), &feed)
+	require.NoError(t, err)
+	assert.Equal(t, config.Duration(14*24*time.Hour), feed.OlderThan)
+
+	err = yaml.Unmarshal([]byte("id: feed/1\nolder_than: soon\n"), &feed)
+	require.Error(t, err)
+}
+
+func TestDuration_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "36h0m0s", config.Duration(36*time.Hour).String())
+	assert.Equal(t, "3d", config.Duration(3*24*time.Hour).String())
+	assert.Equal(t, "2w", config.Duration(14*24*time.Hour).String())
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ErrBadPattern is returned when a glob pattern is malformed, like an unclosed character class
+var ErrBadPattern = errors.New("syntax error in pattern")
+
+// CompileGlob compiles a glob pattern (ex: "*news*") to a case-insensitive regular expression matching whole names.
+// Unlike path.Match, "*" matches any sequence of characters, including "/", since feed titles and category names are not paths.
+// "?" matches any single character, "[...]" a character class, negated with "[!...]" or "[^...]", and "\" escapes the next character.
+func CompileGlob(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '\\':
+			i++
+			if i == len(runes) {
+				return nil, ErrBadPattern
+			}
+			b.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case '[':
+			end, err := writeGlobClass(&b, runes, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteString("$")
+
+	// Invalid ranges, like "[z-a]", are only detected by the regular expression compiler
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, ErrBadPattern
+	}
+
+	return re, nil
+}
+
+// writeGlobClass writes the character class starting at runes[start] as a regular expression, and returns the index of its closing "]"
+func writeGlobClass(b *strings.Builder, runes []rune, start int) (int, error) {
+	b.WriteString("[")
+
+	i := start + 1
+	if i < len(runes) && (runes[i] == '!' || runes[i] == '^') {
+		b.WriteString("^")
+		i++
+	}
+
+	first := i
+	for ; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == ']' && i > first:
+			b.WriteString("]")
+			return i, nil
+		case r == '\\' && i+1 < len(runes):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(runes[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	return 0, ErrBadPattern
+}
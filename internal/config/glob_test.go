@@ -0,0 +1,54 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+)
+
+func TestCompileGlob(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "*news*", name: "Hacker News", expected: true},
+		{pattern: "*news*", name: "r/worldnews", expected: true},
+		{pattern: "*news*", name: "BBC News - UK/World", expected: true},
+		{pattern: "*12", name: "feed/12", expected: true},
+		{pattern: "News/*", name: "News/World/Europe", expected: true},
+		{pattern: "news", name: "Hacker News", expected: false},
+		{pattern: "go ?log", name: "Go Blog", expected: true},
+		{pattern: "feed/[0-9]", name: "feed/7", expected: true},
+		{pattern: "feed/[!0-9]", name: "feed/7", expected: false},
+		{pattern: "feed/[^0-9]", name: "feed/a", expected: true},
+		{pattern: "c++ (weekly)", name: "C++ (Weekly)", expected: true},
+		{pattern: `\*starred\*`, name: "*Starred*", expected: true},
+		{pattern: `\*starred\*`, name: "Starred", expected: false},
+	}
+
+	for _, tc := range tests {
+		tc := tc // capture range variable
+		t.Run(tc.pattern+" "+tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			re, err := config.CompileGlob(tc.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, re.MatchString(tc.name))
+		})
+	}
+
+	t.Run("WithInvalidPatterns", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{"[news", "[]", `news\`, "[z-a]"} {
+			_, err := config.CompileGlob(pattern)
+			assert.ErrorIs(t, err, config.ErrBadPattern, pattern)
+		}
+	})
+}
@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"os"
 	"regexp"
-
-	"gopkg.in/yaml.v3"
 )
 
 const filename = "freshrss-cleaner.yaml"
@@ -39,7 +37,8 @@ func replaceEnv(data []byte) []byte {
 // Load loads the configuration file from the specified path and returns a RootConfig struct.
 // The configuration file should be in YAML format.
 // Environment variables specified in the config file with env("VAR_NAME") will be replaced with their values.
-// Returns an error if the config file cannot be read or parsed.
+// Returns an error if the config file cannot be read or parsed, or contains unknown fields.
+// Use Validate to check the values of the configuration as well.
 func Load(configPath string) (*RootConfig, error) {
 	if configPath == "" {
 		return nil, fmt.Errorf("config path cannot be empty")
@@ -54,9 +53,9 @@ func Load(configPath string) (*RootConfig, error) {
 	// Replace environment variables in the config data
 	configData = replaceEnv(configData)
 
-	// Parse the configuration file
+	// Parse the configuration file, failing on unknown fields, which are most likely typos
 	var config RootConfig
-	if err = yaml.Unmarshal(configData, &config); err != nil {
+	if err = decodeStrict(configData, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
 	}
 
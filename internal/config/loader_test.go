@@ -2,6 +2,7 @@ package config_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -29,11 +30,13 @@ func TestLoad(t *testing.T) {
 		assert.Equal(t, "https://example.com", cfg.URL)
 		assert.Equal(t, "user", cfg.Username)
 		assert.Equal(t, "pass", cfg.Password)
-		assert.Len(t, cfg.Feeds, 2)
+		assert.Len(t, cfg.Feeds, 3)
 		assert.Equal(t, "feed1", cfg.Feeds[0].ID)
 		assert.Equal(t, 7, cfg.Feeds[0].Days)
 		assert.Equal(t, "feed2", cfg.Feeds[1].ID)
 		assert.Equal(t, 14, cfg.Feeds[1].Days)
+		assert.Equal(t, "user/-/label/News", cfg.Feeds[2].ID)
+		assert.Equal(t, config.Duration(36*time.Hour), cfg.Feeds[2].OlderThan)
 	})
 
 	t.Run("With invalid config file", func(t *testing.T) {
@@ -43,6 +46,14 @@ func TestLoad(t *testing.T) {
 		assert.Contains(t, err.Error(), "failed to parse config file")
 	})
 
+	t.Run("With unknown fields in config file", func(t *testing.T) {
+		configFile := "testdata/unknown_field_config.yaml"
+		_, err := config.Load(configFile)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "line 4: field api_key not found")
+		assert.Contains(t, err.Error(), "line 7: field dayz not found")
+	})
+
 	t.Run("With valid config file with env var replacement", func(t *testing.T) {
 		configFile := "testdata/valid_config_with_env.yaml"
 
@@ -0,0 +1,450 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem describes an issue found in a configuration file
+type Problem struct {
+	// Line is the line of the configuration file where the problem was found, or 0 when it is not related to a specific line
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// yamlLinePattern matches the line number at the start of the errors reported by the YAML parser
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// decodeStrict parses the configuration data, failing on fields that don't exist in the configuration
+func decodeStrict(configData []byte, cfg *RootConfig) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(configData))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
+
+// Validate checks the configuration data for every problem it can find, like unknown fields, missing required fields,
+// invalid feed rules or unresolved environment variables, and returns them ordered by line. It returns no problems when the configuration is valid.
+func Validate(configData []byte) []Problem {
+	problems := unresolvedEnvVars(configData)
+	configData = replaceEnv(configData)
+
+	var cfg RootConfig
+	if err := decodeStrict(configData, &cfg); err != nil {
+		problems = append(problems, parseProblems(err)...)
+
+		// Type errors still decode the rest of the file, but syntax errors leave nothing else to check
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return problems
+		}
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(configData, &root); err != nil {
+		return problems
+	}
+
+	problems = append(problems, validateConfig(&cfg, documentMapping(&root))...)
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+
+	return problems
+}
+
+// FeedLines returns the line where each feed is defined in the configuration data, or 0 when it can't be found
+func FeedLines(configData []byte) []int {
+	var root yaml.Node
+	if err := yaml.Unmarshal(replaceEnv(configData), &root); err != nil {
+		return nil
+	}
+
+	feedNodes := mappingValue(documentMapping(&root), "feeds")
+	if feedNodes == nil {
+		return nil
+	}
+
+	lines := make([]int, 0, len(feedNodes.Content))
+	for _, node := range feedNodes.Content {
+		lines = append(lines, node.Line)
+	}
+
+	return lines
+}
+
+// unresolvedEnvVars reports the env("VAR_NAME") placeholders referencing environment variables that are not set
+func unresolvedEnvVars(configData []byte) []Problem {
+	var problems []Problem
+	for _, match := range envVarRegex.FindAllSubmatchIndex(configData, -1) {
+		name := string(configData[match[2]:match[3]])
+		if _, ok := os.LookupEnv(name); !ok {
+			problems = append(problems, Problem{
+				Line:    bytes.Count(configData[:match[0]], []byte("\n")) + 1,
+				Message: fmt.Sprintf("environment variable %s is not set", name),
+			})
+		}
+	}
+
+	return problems
+}
+
+// parseProblems converts the errors of the YAML parser to problems, extracting their line numbers
+func parseProblems(err error) []Problem {
+	messages := []string{err.Error()}
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+
+	problems := make([]Problem, 0, len(messages))
+	for _, message := range messages {
+		problem := Problem{Message: message}
+		if m := yamlLinePattern.FindStringSubmatch(message); m != nil {
+			problem.Line, _ = strconv.Atoi(m[1])
+			problem.Message = m[2]
+		}
+		problems = append(problems, problem)
+	}
+
+	return problems
+}
+
+// validateConfig checks the values of the configuration, using the YAML nodes to find the lines of the problems
+func validateConfig(cfg *RootConfig, root *yaml.Node) []Problem {
+	problems := validateCredentials(cfg, root)
+	add := func(node *yaml.Node, format string, args ...any) {
+		problems = append(problems, Problem{Line: nodeLine(node), Message: fmt.Sprintf(format, args...)})
+	}
+
+	if err := cfg.FailurePolicy.Validate(); err != nil {
+		add(mappingValue(root, "failure_policy"), "%v", err)
+	}
+
+	if cfg.Concurrency < 0 {
+		add(mappingValue(root, "concurrency"), "concurrency can't be negative")
+	}
+
+	if cfg.RateLimit < 0 {
+		add(mappingValue(root, "rate_limit"), "rate_limit can't be negative")
+	}
+
+	problems = append(problems, validateRetry(cfg.Retry, mappingValue(root, "retry"))...)
+
+	if cfg.Journal.KeepRuns < 0 {
+		add(mappingValue(mappingValue(root, "journal"), "keep_runs"), "journal keep_runs can't be negative")
+	}
+
+	if len(cfg.Feeds) == 0 && cfg.Default == nil {
+		add(mappingValue(root, "feeds"), "at least one feed or a default rule is required")
+	}
+
+	if cfg.Default != nil {
+		problems = append(problems, validateDefault(*cfg.Default, mappingValue(root, "default"))...)
+	}
+
+	return append(problems, validateFeeds(cfg.Feeds, mappingValue(root, "feeds"))...)
+}
+
+// validateCredentials checks the URL of the server and the credentials used to log in
+func validateCredentials(cfg *RootConfig, root *yaml.Node) []Problem {
+	var problems []Problem
+	add := func(node *yaml.Node, format string, args ...any) {
+		problems = append(problems, Problem{Line: nodeLine(node), Message: fmt.Sprintf(format, args...)})
+	}
+
+	if cfg.URL == "" {
+		add(mappingValue(root, "url"), "url is required")
+	} else if _, err := url.ParseRequestURI(cfg.URL); err != nil {
+		add(mappingValue(root, "url"), "invalid url: %v", err)
+	}
+
+	// A pre-obtained auth token doesn't require the credentials
+	if cfg.AuthToken == "" {
+		if cfg.Username == "" {
+			add(mappingValue(root, "username"), "username is required")
+		}
+
+		if cfg.Password == "" {
+			add(mappingValue(root, "password"), "password or auth_token is required")
+		}
+	}
+
+	return problems
+}
+
+// validateRetry checks the retry policy of the API requests
+func validateRetry(retry RetryConfig, node *yaml.Node) []Problem {
+	var problems []Problem
+	if retry.MaxAttempts < 0 {
+		problems = append(problems, Problem{Line: nodeLine(mappingValue(node, "max_attempts")), Message: "retry max_attempts can't be negative"})
+	}
+
+	if retry.Jitter != nil && (*retry.Jitter < 0 || *retry.Jitter > 1) {
+		problems = append(problems, Problem{Line: nodeLine(mappingValue(node, "jitter")), Message: "retry jitter must be between 0 and 1"})
+	}
+
+	return problems
+}
+
+// validateFeeds checks the rules of the feeds array, and that the same target is not defined twice
+func validateFeeds(feeds []FeedConfig, feedNodes *yaml.Node) []Problem {
+	var problems []Problem
+	definedAt := make(map[string]int)
+	for i, feed := range feeds {
+		var node *yaml.Node
+		if feedNodes != nil && i < len(feedNodes.Content) {
+			node = feedNodes.Content[i]
+		}
+
+		name := feedName(i, feed)
+		problems = append(problems, validateFeed(name, feed, node)...)
+
+		// Rules with match or exclude filters are meant to be combined with other rules for the same feed
+		if feed.targets() != 1 || feed.HasFilters() {
+			continue
+		}
+
+		if line, ok := definedAt[feed.Target()]; ok {
+			problems = append(problems, Problem{Line: nodeLine(node), Message: fmt.Sprintf("%s is already defined at line %d", name, line)})
+			continue
+		}
+		definedAt[feed.Target()] = nodeLine(node)
+	}
+
+	return problems
+}
+
+// feedName returns the name of the feed used in the problems: its target, or its position when the target is missing or ambiguous
+func feedName(i int, feed FeedConfig) string {
+	if feed.targets() != 1 {
+		return fmt.Sprintf("feed #%d", i+1)
+	}
+
+	return fmt.Sprintf("feed %q", feed.Target())
+}
+
+// validateFeed checks the target and the rule of a feed of the feeds array
+func validateFeed(name string, feed FeedConfig, node *yaml.Node) []Problem {
+	var problems []Problem
+	add := func(node *yaml.Node, format string, args ...any) {
+		problems = append(problems, Problem{Line: nodeLine(node), Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch feed.targets() {
+	case 0:
+		add(node, "%s: one of id, feed_title, feed_url, category, feeds_matching, category_matching or all_feeds is required", name)
+	case 1:
+	default:
+		add(node, "%s: only one of id, feed_title, feed_url, category, feeds_matching, category_matching or all_feeds can be set", name)
+	}
+
+	if feed.Skip && feed.hasRule() {
+		add(mappingValue(node, "skip"), "%s: skip can't be combined with days, older_than, keep_unread, match or exclude", name)
+	}
+
+	problems = append(problems, validateRule(name, feed, node)...)
+	problems = append(problems, validatePatterns(name, feed, node)...)
+
+	return problems
+}
+
+// validateDefault checks the default rule, which applies to every feed without its own rule, so it can't target feeds, skip them or have a priority
+func validateDefault(feed FeedConfig, node *yaml.Node) []Problem {
+	const name = "default"
+
+	var problems []Problem
+	if feed.targets() > 0 {
+		problems = append(problems, Problem{
+			Line:    nodeLine(node),
+			Message: fmt.Sprintf("%s: id, feed_title, feed_url, category, feeds_matching, category_matching and all_feeds can't be set in the default rule", name),
+		})
+	}
+
+	if feed.Skip {
+		problems = append(problems, Problem{
+			Line:    nodeLine(mappingValue(node, "skip")),
+			Message: fmt.Sprintf("%s: skip can't be set in the default rule", name),
+		})
+	}
+
+	if feed.Priority != 0 {
+		problems = append(problems, Problem{
+			Line:    nodeLine(mappingValue(node, "priority")),
+			Message: fmt.Sprintf("%s: priority can't be set in the default rule, it always applies after the other rules", name),
+		})
+	}
+
+	// Without any rule, the default rule would mark every item of the feeds without their own rule as read.
+	// Invalid days or older_than values are already reported by validateRule.
+	if !feed.hasRule() && mappingValue(node, "days") == nil && mappingValue(node, "older_than") == nil {
+		problems = append(problems, Problem{
+			Line:    nodeLine(node),
+			Message: fmt.Sprintf("%s: one of days, older_than, keep_unread, match or exclude is required", name),
+		})
+	}
+
+	problems = append(problems, validateRule(name, feed, node)...)
+	problems = append(problems, validatePatterns(name, feed, node)...)
+
+	return problems
+}
+
+// validateRule checks the fields selecting the items of the feed to mark as read
+func validateRule(name string, feed FeedConfig, node *yaml.Node) []Problem {
+	var problems []Problem
+	add := func(node *yaml.Node, format string, args ...any) {
+		problems = append(problems, Problem{Line: nodeLine(node), Message: fmt.Sprintf(format, args...)})
+	}
+
+	if days := mappingValue(node, "days"); days != nil && feed.Days <= 0 {
+		add(days, "%s: days must be greater than zero", name)
+	}
+
+	// Durations that can't be parsed are already reported by the YAML decoder
+	if olderThan := mappingValue(node, "older_than"); olderThan != nil && feed.OlderThan <= 0 {
+		if _, err := ParseDuration(olderThan.Value); err == nil {
+			add(olderThan, "%s: older_than must be greater than zero", name)
+		}
+	}
+
+	if feed.KeepUnread < 0 {
+		add(mappingValue(node, "keep_unread"), "%s: keep_unread can't be negative", name)
+	}
+
+	problems = append(problems, validateItemFilter(name, "match", feed.Match, mappingValue(node, "match"))...)
+	problems = append(problems, validateItemFilter(name, "exclude", feed.Exclude, mappingValue(node, "exclude"))...)
+
+	return problems
+}
+
+// validatePatterns checks that the glob patterns of the feed are valid, and that exclusions are only used with rules targeting many feeds
+func validatePatterns(name string, feed FeedConfig, node *yaml.Node) []Problem {
+	var problems []Problem
+	check := func(key string, pattern string, valueNode *yaml.Node) {
+		if _, err := CompileGlob(pattern); err != nil {
+			problems = append(problems, Problem{
+				Line:    nodeLine(valueNode),
+				Message: fmt.Sprintf("%s: invalid %s pattern %q: %v", name, key, pattern, err),
+			})
+		}
+	}
+
+	if feed.FeedsMatching != "" {
+		check("feeds_matching", feed.FeedsMatching, mappingValue(node, "feeds_matching"))
+	}
+
+	if feed.CategoryMatching != "" {
+		check("category_matching", feed.CategoryMatching, mappingValue(node, "category_matching"))
+	}
+
+	for _, key := range []string{"exclude_feeds", "exclude_categories"} {
+		excludeNode := mappingValue(node, key)
+		if excludeNode == nil {
+			continue
+		}
+
+		if !feed.IsPattern() {
+			problems = append(problems, Problem{
+				Line:    nodeLine(excludeNode),
+				Message: fmt.Sprintf("%s: %s can only be used with feeds_matching, category_matching or all_feeds", name, key),
+			})
+			continue
+		}
+
+		for _, patternNode := range excludeNode.Content {
+			check(key, patternNode.Value, patternNode)
+		}
+	}
+
+	return problems
+}
+
+// validateItemFilter checks that the fields of a match or exclude filter are valid regular expressions
+func validateItemFilter(name string, kind string, filter *ItemFilter, node *yaml.Node) []Problem {
+	if filter == nil {
+		return nil
+	}
+
+	fields := []struct {
+		name    string
+		pattern string
+	}{
+		{"title", filter.Title},
+		{"author", filter.Author},
+		{"url", filter.URL},
+		{"content", filter.Content},
+	}
+
+	var problems []Problem
+	for _, field := range fields {
+		if field.pattern == "" {
+			continue
+		}
+
+		if _, err := regexp.Compile(field.pattern); err != nil {
+			problems = append(problems, Problem{
+				Line:    nodeLine(mappingValue(node, field.name)),
+				Message: fmt.Sprintf("%s: invalid %s rule: %s: %v", name, kind, field.name, err),
+			})
+		}
+	}
+
+	return problems
+}
+
+// documentMapping returns the top level mapping of a YAML document, or nil when the document is not a mapping
+func documentMapping(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 && doc.Content[0].Kind == yaml.MappingNode {
+		return doc.Content[0]
+	}
+
+	return nil
+}
+
+// mappingValue returns the value of the key in a YAML mapping node, or nil when the node is not a mapping or doesn't have the key
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// nodeLine returns the line of a YAML node, or 0 when the node doesn't exist
+func nodeLine(node *yaml.Node) int {
+	if node == nil {
+		return 0
+	}
+
+	return node.Line
+}
@@ -0,0 +1,127 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("With valid config file", func(t *testing.T) {
+		configData, err := os.ReadFile("testdata/valid_config.yaml")
+		require.NoError(t, err)
+
+		assert.Empty(t, config.Validate(configData))
+	})
+
+	t.Run("With default config", func(t *testing.T) {
+		assert.Empty(t, config.Validate([]byte(config.DefaultConfig)))
+	})
+
+	t.Run("With empty config", func(t *testing.T) {
+		problems := config.Validate(nil)
+
+		assert.Equal(t, []config.Problem{
+			{Message: "url is required"},
+			{Message: "username is required"},
+			{Message: "password or auth_token is required"},
+			{Message: "at least one feed or a default rule is required"},
+		}, problems)
+	})
+
+	t.Run("With auth token instead of credentials", func(t *testing.T) {
+		problems := config.Validate([]byte("url: https://example.com\nauth_token: user/token\nfeeds:\n  - id: feed1\n"))
+
+		assert.Empty(t, problems)
+	})
+
+	t.Run("With default rule", func(t *testing.T) {
+		problems := config.Validate([]byte("url: https://example.com\nauth_token: user/token\ndefault:\n  older_than: 30d\n"))
+		assert.Empty(t, problems)
+
+		problems = config.Validate([]byte(`url: https://example.com
+auth_token: user/token
+default:
+  all_feeds: true
+  skip: true
+  days: 0
+  priority: 10
+feeds:
+  - category: News
+    skip: true
+  - id: feed1
+    skip: true
+    days: 7
+`))
+
+		assert.Equal(t, []config.Problem{
+			{Line: 4, Message: "default: id, feed_title, feed_url, category, feeds_matching, category_matching and all_feeds can't be set in the default rule"},
+			{Line: 5, Message: "default: skip can't be set in the default rule"},
+			{Line: 6, Message: "default: days must be greater than zero"},
+			{Line: 7, Message: "default: priority can't be set in the default rule, it always applies after the other rules"},
+			{Line: 12, Message: `feed "feed1": skip can't be combined with days, older_than, keep_unread, match or exclude`},
+		}, problems)
+	})
+
+	t.Run("With empty default rule", func(t *testing.T) {
+		problems := config.Validate([]byte("url: https://example.com\nauth_token: user/token\ndefault: {}\n"))
+
+		assert.Equal(t, []config.Problem{
+			{Line: 3, Message: "default: one of days, older_than, keep_unread, match or exclude is required"},
+		}, problems)
+	})
+
+	t.Run("With negative journal keep_runs", func(t *testing.T) {
+		problems := config.Validate([]byte("url: https://example.com\nauth_token: user/token\njournal:\n  keep_runs: -1\nfeeds:\n  - id: feed1\n"))
+
+		assert.Equal(t, []config.Problem{{Line: 4, Message: "journal keep_runs can't be negative"}}, problems)
+	})
+
+	t.Run("With syntax error", func(t *testing.T) {
+		problems := config.Validate([]byte("url: https://example.com\nfeeds: [\n"))
+
+		require.Len(t, problems, 1)
+		assert.Equal(t, 2, problems[0].Line)
+	})
+
+	t.Run("With invalid duration and unknown field", func(t *testing.T) {
+		problems := config.Validate([]byte("url: https://example.com\nauth_token: user/token\nfeeds:\n  - id: feed1\n    older_than: 3x\n    dayz: 7\n"))
+
+		assert.Equal(t, []config.Problem{
+			{Line: 5, Message: `invalid duration "3x": expected a value like 36h, 3d, 2w or 1mo`},
+			{Line: 6, Message: "field dayz not found in type config.FeedConfig"},
+		}, problems)
+	})
+
+	t.Run("With invalid values", func(t *testing.T) {
+		configData, err := os.ReadFile("testdata/invalid_values_config.yaml")
+		require.NoError(t, err)
+
+		problems := config.Validate(configData)
+
+		messages := make([]string, 0, len(problems))
+		for _, problem := range problems {
+			messages = append(messages, problem.String())
+		}
+
+		assert.Equal(t, []string{
+			"line 3: environment variable FRESHRSS_CLEANER_TEST_UNSET_PASSWORD is not set",
+			"line 3: password or auth_token is required",
+			`line 4: invalid failure policy "sometimes": must be one of continue, fail-fast or fail-at-end`,
+			`line 8: feed #2: one of id, feed_title, feed_url, category, feeds_matching, category_matching or all_feeds is required`,
+			`line 11: feed "feed2": days must be greater than zero`,
+			`line 12: feed "feed1" is already defined at line 6`,
+			`line 16: feed "feed1": invalid match rule: title: error parsing regexp: missing closing ): ` + "`(unclosed`",
+			"line 18: field unknown not found in type config.FeedConfig",
+			`line 19: feed #7: only one of id, feed_title, feed_url, category, feeds_matching, category_matching or all_feeds can be set`,
+			`line 23: feed "category=\"News\"" is already defined at line 21`,
+			`line 25: feed "feeds_matching=\"[news\"": invalid feeds_matching pattern "[news": syntax error in pattern`,
+			`line 27: feed "feed5": exclude_feeds can only be used with feeds_matching, category_matching or all_feeds`,
+			`line 31: feed "all_feeds": invalid exclude_categories pattern "[sports": syntax error in pattern`,
+		}, messages)
+	})
+}
@@ -2,22 +2,47 @@ package freshrss
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/brpaz/freshrss-cleaner/internal/config"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
 )
 
+// ErrAuthentication is returned when the cleaner fails to authenticate against the FreshRSS API
+var ErrAuthentication = errors.New("authentication failed")
+
+// streamPageSize is the number of items requested per page when reading stream contents
+const streamPageSize = 1000
+
 // API defines the interface for the FreshRSS API client
 type API interface {
 	GetAuthToken(ctx context.Context) (string, error)
-	MarkAsRead(ctx context.Context, authToken string, feedID string, days int) error
+	MarkAsRead(ctx context.Context, authToken string, feedID string, olderThan time.Time) error
+	StreamContents(ctx context.Context, authToken string, streamID string, opts client.StreamOptions) (*client.StreamContents, error)
+	EditTag(ctx context.Context, authToken string, itemIDs []string, add []string, remove []string) error
+	UnreadCounts(ctx context.Context, authToken string) (map[string]int, error)
+	SubscriptionList(ctx context.Context, authToken string) ([]client.Subscription, error)
+	TagList(ctx context.Context, authToken string) ([]client.Tag, error)
 }
 
 // Cleaner is a struct that represents a Freshrss cleaner
 type Cleaner struct {
-	client API
-	config *config.RootConfig
+	client   API
+	config   *config.RootConfig
+	dryRun   bool
+	observer Observer
+	tokens   TokenStore
+	journal  Journal
+
+	// authToken is reused between runs, and only refreshed when rejected by the API
+	authMu    sync.Mutex
+	authToken string
 }
 
 // Validate checks if the Freshrss cleaner is properly configured with all the required fields
@@ -46,6 +71,58 @@ func WithConfig(cfg *config.RootConfig) CleanerOption {
 	}
 }
 
+// WithDryRun enables the dry-run mode, in which the cleaner only reports the items that would be marked as read
+func WithDryRun(dryRun bool) CleanerOption {
+	return func(c *Cleaner) {
+		c.dryRun = dryRun
+	}
+}
+
+// Observer is notified about the outcome of the cleaner runs, for example to collect metrics
+type Observer interface {
+	// ObserveAuthFailure is called every time the cleaner fails to get an auth token
+	ObserveAuthFailure()
+	// ObserveFeedResult is called with the result of every processed feed, once the run finishes
+	ObserveFeedResult(result FeedResult)
+	// ObserveRuleResult is called with the outcome of every rule of the run, identified by its target, once the run finishes
+	ObserveRuleResult(rule string, succeeded bool)
+}
+
+// TokenStore persists the auth token between runs, so the cleaner doesn't need to log in every time
+type TokenStore interface {
+	// Load returns the stored token, or an empty string when there is none
+	Load() (string, error)
+	Save(token string) error
+	Clear() error
+}
+
+// Journal records the items marked as read by every run, so they can be marked as unread again
+type Journal interface {
+	Record(runID string, feedID string, itemIDs []string) error
+}
+
+// WithJournal sets the journal recording the items marked as read. Feeds are then marked item by item,
+// instead of with mark-all-as-read, which doesn't report the items it marks.
+func WithJournal(journal Journal) CleanerOption {
+	return func(c *Cleaner) {
+		c.journal = journal
+	}
+}
+
+// WithTokenStore sets the store used to reuse the auth token between runs
+func WithTokenStore(store TokenStore) CleanerOption {
+	return func(c *Cleaner) {
+		c.tokens = store
+	}
+}
+
+// WithObserver sets an observer notified about the outcome of the cleaner runs
+func WithObserver(observer Observer) CleanerOption {
+	return func(c *Cleaner) {
+		c.observer = observer
+	}
+}
+
 // Option defines a function to configure the FreshRSS client
 type CleanerOption func(*Cleaner)
 
@@ -64,27 +141,579 @@ func NewCleaner(opts ...CleanerOption) (*Cleaner, error) {
 	return cleaner, nil
 }
 
-// CleanOldEntries cleans up old entries from FreshRSS based on the provided configuration
-func (c *Cleaner) CleanOldEntries(ctx context.Context, log *slog.Logger) error {
+// CleanOldEntries cleans up old entries from FreshRSS based on the provided configuration, including the default rule
+func (c *Cleaner) CleanOldEntries(ctx context.Context, log *slog.Logger) (*Result, error) {
+	return c.CleanFeeds(ctx, log, c.config.Rules())
+}
+
+// CleanFeeds cleans up old entries from FreshRSS for the specified subset of the configured feeds
+func (c *Cleaner) CleanFeeds(ctx context.Context, log *slog.Logger, feeds []config.FeedConfig) (*Result, error) {
+	start := time.Now()
+	runID := newRunID(start)
+
+	authToken, err := c.getAuthToken(ctx, log, "")
+	if err != nil {
+		return nil, err
+	}
+
+	unreadBefore := c.unreadCounts(ctx, log, &authToken)
+	targets := c.resolveFeeds(ctx, authToken, feeds)
+
+	feedResults, err := c.runFeeds(ctx, log, runID, targets, unreadBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(feedResults) < len(targets) {
+		log.Warn("Stopped on first failure, as configured by the failure policy", "skipped_feeds", len(targets)-len(feedResults))
+	}
+
+	unreadAfter := unreadBefore
+	if !c.dryRun {
+		unreadAfter = c.unreadCounts(ctx, log, &authToken)
+	}
+
+	result := &Result{RunID: runID, Feeds: feedResults}
+	for i := range result.Feeds {
+		feedResult := &result.Feeds[i]
+		feedResult.UnreadAfter = unreadAfter[feedResult.FeedID]
+
+		if feedResult.markedFromUnreadCounts {
+			feedResult.Marked = max(feedResult.UnreadBefore-feedResult.UnreadAfter, 0)
+		}
+
+		if c.observer != nil {
+			c.observer.ObserveFeedResult(*feedResult)
+		}
+	}
+
+	if c.observer != nil {
+		c.observeRules(feeds, targets, result.Feeds)
+	}
+
+	result.Duration = time.Since(start)
+
+	return result, nil
+}
+
+// observeRules notifies the observer about the outcome of the rules. A rule succeeds when all its feeds were processed without errors,
+// which is also the case when it targets no feed. Rules sharing the same target succeed only when all of them do.
+func (c *Cleaner) observeRules(rules []config.FeedConfig, targets []target, results []FeedResult) {
+	pending := make(map[int]int)
+	for _, t := range targets {
+		pending[t.feed.Position()]++
+	}
+
+	for _, r := range results {
+		if r.Err == nil {
+			pending[r.position]--
+		}
+	}
+
+	succeeded := make(map[string]bool)
+	var order []string
+	for _, rule := range rules {
+		if rule.Skip {
+			continue
+		}
+
+		ok, seen := succeeded[rule.Target()]
+		if !seen {
+			order = append(order, rule.Target())
+			ok = true
+		}
+		succeeded[rule.Target()] = ok && pending[rule.Position()] == 0
+	}
+
+	for _, target := range order {
+		c.observer.ObserveRuleResult(target, succeeded[target])
+	}
+}
+
+// runFeeds processes the feeds concurrently, and returns their results in the order of the feeds
+func (c *Cleaner) runFeeds(ctx context.Context, log *slog.Logger, runID string, targets []target, unreadBefore map[string]int) ([]FeedResult, error) {
+	workers := min(max(c.config.Concurrency, 1), len(targets))
+
+	// No more feeds are started after a failure to log in again, or a failed feed with the fail-fast policy
+	stopCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		authErr error
+	)
+
+	results := make([]*FeedResult, len(targets))
+	jobs := make(chan int)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// The dispatcher may still send a job while stopping, which must not be started
+				if stopCtx.Err() != nil {
+					continue
+				}
+
+				feedResult, err := c.runTarget(ctx, log, runID, targets[i], unreadBefore)
+				if err != nil {
+					mu.Lock()
+					if authErr == nil {
+						authErr = err
+					}
+					mu.Unlock()
+					stop()
+					continue
+				}
+
+				results[i] = &feedResult
+				if feedResult.Err != nil && c.config.FailurePolicy.OrDefault() == config.FailurePolicyFailFast {
+					stop()
+				}
+			}
+		}()
+	}
+
+	dispatch(stopCtx, jobs, len(targets))
+	wg.Wait()
+
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	return collectResults(ctx, targets, results, unreadBefore), nil
+}
+
+// dispatch sends the indexes of the targets to the workers, until the context is done, and closes the jobs channel
+func dispatch(ctx context.Context, jobs chan<- int, count int) {
+	defer close(jobs)
+
+	for i := 0; i < count; i++ {
+		if ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case jobs <- i:
+		}
+	}
+}
+
+// runTarget processes a feed, or reports it as failed when it couldn't be resolved by name
+func (c *Cleaner) runTarget(ctx context.Context, log *slog.Logger, runID string, t target, unreadBefore map[string]int) (FeedResult, error) {
+	log.Info("Processing feed", "feed_id", t.feed.Target())
+
+	result := FeedResult{FeedID: t.feed.Target(), Rule: t.feed.Rule(), Err: t.err}
+	if t.err == nil {
+		var err error
+		if result, err = c.runFeed(ctx, log, runID, t.feed, unreadBefore[t.feed.ID]); err != nil {
+			return result, err
+		}
+	}
+
+	if result.Err != nil {
+		log.Error("Failed to process feed", "feed_id", result.FeedID, "error", result.Err)
+	}
+
+	result.position = t.feed.Position()
+
+	return result, nil
+}
+
+// collectResults returns the results of the feeds in their order. When the context is done, the feeds never started are reported as failed.
+func collectResults(ctx context.Context, targets []target, results []*FeedResult, unreadBefore map[string]int) []FeedResult {
+	feedResults := make([]FeedResult, 0, len(targets))
+	for i, feedResult := range results {
+		switch {
+		case feedResult != nil:
+			feedResults = append(feedResults, *feedResult)
+		case ctx.Err() != nil:
+			feed := targets[i].feed
+			feedResults = append(feedResults, notStartedResult(feed, unreadBefore[feed.ID], ctx.Err()))
+		}
+	}
+
+	return feedResults
+}
+
+// notStartedResult returns the result of a feed that was not processed because the run was cancelled, without calling the API
+func notStartedResult(feed config.FeedConfig, unreadBefore int, err error) FeedResult {
+	feedID := feed.ID
+	if feedID == "" {
+		// Feeds that couldn't be resolved by name have no ID
+		feedID = feed.Target()
+	}
+
+	return FeedResult{FeedID: feedID, Rule: feed.Rule(), UnreadBefore: unreadBefore, Err: err, position: feed.Position()}
+}
+
+// runFeed processes a single feed and returns its result. When the API rejects the auth token, for example because
+// it expired in a long running daemon, it logs in again and retries the feed once. Only failures to log in again are returned as errors.
+func (c *Cleaner) runFeed(ctx context.Context, log *slog.Logger, runID string, feed config.FeedConfig, unreadBefore int) (FeedResult, error) {
+	authToken, err := c.getAuthToken(ctx, log, "")
+	if err != nil {
+		return FeedResult{}, err
+	}
+
+	var result FeedResult
+	for attempt := 0; attempt < 2; attempt++ {
+		result = FeedResult{
+			FeedID:       feed.ID,
+			Rule:         feed.Rule(),
+			UnreadBefore: unreadBefore,
+			runID:        runID,
+		}
+
+		start := time.Now()
+		result.Err = c.processFeed(ctx, log, feed, authToken, &result)
+		result.Duration = time.Since(start)
+
+		if !errors.Is(result.Err, client.ErrUnauthorized) || attempt > 0 {
+			break
+		}
+
+		log.Warn("Auth token rejected by the API, logging in again", "feed_id", feed.ID)
+		if authToken, err = c.getAuthToken(ctx, log, authToken); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// getAuthToken returns the cached auth token, either from memory or the token store, logging in when there is none yet.
+// When rejected is set to the token refused by the API, a new token is requested, unless it was already refreshed meanwhile.
+func (c *Cleaner) getAuthToken(ctx context.Context, log *slog.Logger, rejected string) (string, error) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	if c.authToken != "" && c.authToken != rejected {
+		return c.authToken, nil
+	}
+
+	if c.authToken == "" && rejected == "" {
+		if authToken := c.loadCachedToken(log); authToken != "" {
+			log.Info("Reusing cached auth token")
+			c.authToken = authToken
+			return authToken, nil
+		}
+	}
+
+	if rejected != "" {
+		c.clearCachedToken(log)
+	}
+
+	return c.login(ctx, log)
+}
+
+// loadCachedToken returns the auth token of the token store, or an empty string when there is none
+func (c *Cleaner) loadCachedToken(log *slog.Logger) string {
+	if c.tokens == nil {
+		return ""
+	}
+
+	authToken, err := c.tokens.Load()
+	if err != nil {
+		log.Warn("Failed to load the cached auth token", "error", err)
+	}
+
+	return authToken
+}
+
+// clearCachedToken removes the auth token from the token store, if any
+func (c *Cleaner) clearCachedToken(log *slog.Logger) {
+	if c.tokens == nil {
+		return
+	}
+
+	if err := c.tokens.Clear(); err != nil {
+		log.Warn("Failed to clear the cached auth token", "error", err)
+	}
+}
+
+// login requests a new auth token, and saves it in the token store, if any. It must be called with authMu held.
+func (c *Cleaner) login(ctx context.Context, log *slog.Logger) (string, error) {
 	log.Info("Fetching auth token")
 	authToken, err := c.client.GetAuthToken(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get auth token: %w", err)
+		c.authToken = ""
+		if c.observer != nil {
+			c.observer.ObserveAuthFailure()
+		}
+		return "", fmt.Errorf("%w: failed to get auth token: %w", ErrAuthentication, err)
 	}
 
-	for _, feed := range c.config.Feeds {
-		log.Info("Processing feed", "feed_id", feed.ID)
-		err := c.processFeed(ctx, feed, authToken)
-		if err != nil {
-			log.Error("Failed to process feed", "feed_id", feed.ID, "error", err)
-			continue
+	c.authToken = authToken
+
+	if c.tokens != nil {
+		if err := c.tokens.Save(authToken); err != nil {
+			log.Warn("Failed to cache the auth token", "error", err)
+		}
+	}
+
+	return authToken, nil
+}
+
+// unreadCounts returns the number of unread items per stream. Failures are only logged, since the counts are informative.
+// As it is the first request of a run, it logs in again when the auth token is rejected, for example when the cached token expired.
+func (c *Cleaner) unreadCounts(ctx context.Context, log *slog.Logger, authToken *string) map[string]int {
+	counts, err := c.client.UnreadCounts(ctx, *authToken)
+	if errors.Is(err, client.ErrUnauthorized) {
+		log.Warn("Auth token rejected by the API, logging in again")
+
+		var token string
+		if token, err = c.getAuthToken(ctx, log, *authToken); err == nil {
+			*authToken = token
+			counts, err = c.client.UnreadCounts(ctx, token)
+		}
+	}
+
+	if err != nil {
+		log.Warn("Failed to get unread counts", "error", err)
+		return map[string]int{}
+	}
+
+	return counts
+}
+
+func (c *Cleaner) processFeed(ctx context.Context, log *slog.Logger, feed config.FeedConfig, authToken string, result *FeedResult) error {
+	now := time.Now()
+
+	switch {
+	case feed.HasFilters(), c.hasProtectedItems() && (feed.HasMaxAge() || feed.KeepUnread == 0):
+		// mark-all-as-read can't exclude items, so the items must be selected one by one
+		if err := c.markMatchingItems(ctx, log, feed, authToken, now, result); err != nil {
+			return err
+		}
+	case feed.HasMaxAge() || feed.KeepUnread == 0:
+		if err := c.markOldItems(ctx, log, feed, authToken, now, result); err != nil {
+			return err
+		}
+	}
+
+	if feed.KeepUnread > 0 {
+		if err := c.capUnreadItems(ctx, log, feed, authToken, now, result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// markOldItems marks as read all the unread items of the feed older than the configured age.
+// With a journal, the items are listed to be recorded, and marked one by one.
+func (c *Cleaner) markOldItems(ctx context.Context, log *slog.Logger, feed config.FeedConfig, authToken string, now time.Time, result *FeedResult) error {
+	if !c.dryRun && c.journal == nil {
+		result.markedFromUnreadCounts = true
+		return c.client.MarkAsRead(ctx, authToken, feed.ID, feed.Cutoff(now))
+	}
+
+	items, err := c.unreadItems(ctx, authToken, feed.ID, client.StreamOptions{OlderThan: feed.Cutoff(now)})
+	if err != nil {
+		return err
+	}
+
+	return c.markItems(ctx, log, feed.ID, authToken, items, result)
+}
+
+// capUnreadItems marks as read all the unread items of the feed except the newest ones, as configured by keep_unread
+func (c *Cleaner) capUnreadItems(ctx context.Context, log *slog.Logger, feed config.FeedConfig, authToken string, now time.Time, result *FeedResult) error {
+	var opts client.StreamOptions
+	if feed.HasMaxAge() && !feed.HasFilters() {
+		// Older items are already handled by markOldItems
+		opts.NewerThan = feed.Cutoff(now)
+	}
+
+	items, err := c.unreadItems(ctx, authToken, feed.ID, opts)
+	if err != nil {
+		return err
+	}
+
+	// In dry-run mode, the items selected by the match and exclude rules are still unread, but would be read by now
+	items = withoutReportedItems(items, result.reported)
+
+	if len(items) <= feed.KeepUnread {
+		return nil
+	}
+
+	// Items are returned newest first, so everything after the first N items is marked as read
+	return c.markItems(ctx, log, feed.ID, authToken, items[feed.KeepUnread:], result)
+}
+
+// markMatchingItems marks as read the unread items of the feed selected by its match and exclude rules, if any.
+// When the feed also defines a maximum age, only the items older than it are considered.
+func (c *Cleaner) markMatchingItems(ctx context.Context, log *slog.Logger, feed config.FeedConfig, authToken string, now time.Time, result *FeedResult) error {
+	filter, err := newItemFilter(feed)
+	if err != nil {
+		return err
+	}
+
+	var opts client.StreamOptions
+	if feed.HasMaxAge() {
+		opts.OlderThan = feed.Cutoff(now)
+	}
+
+	items, err := c.unreadItems(ctx, authToken, feed.ID, opts)
+	if err != nil {
+		return err
+	}
+
+	var matching []client.Item
+	for _, item := range items {
+		if filter.Matches(item) {
+			matching = append(matching, item)
+		}
+	}
+
+	return c.markItems(ctx, log, feed.ID, authToken, matching, result)
+}
+
+// hasProtectedItems checks if the configuration protects any items from being marked as read
+func (c *Cleaner) hasProtectedItems() bool {
+	return len(c.protectedStreamIDs()) > 0
+}
+
+// protectedStreamIDs returns the stream IDs of the states and labels whose items must never be marked as read
+func (c *Cleaner) protectedStreamIDs() []string {
+	var ids []string
+	if c.config.Protect.StarredEnabled() {
+		ids = append(ids, client.StateStarred)
+	}
+
+	for _, label := range c.config.Protect.Labels {
+		if !strings.HasPrefix(label, "user/") {
+			label = client.LabelPrefix + label
+		}
+		ids = append(ids, label)
+	}
+
+	return ids
+}
+
+// withoutProtectedItems removes the protected items from the list, returning the remaining items and the number of protected ones
+func (c *Cleaner) withoutProtectedItems(items []client.Item) ([]client.Item, int) {
+	protected := c.protectedStreamIDs()
+
+	remaining := make([]client.Item, 0, len(items))
+	for _, item := range items {
+		if !isProtected(item, protected) {
+			remaining = append(remaining, item)
+		}
+	}
+
+	return remaining, len(items) - len(remaining)
+}
+
+// isProtected checks if the item has any of the protected states or labels
+func isProtected(item client.Item, protected []string) bool {
+	for _, id := range protected {
+		if item.HasCategory(id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// markItems marks the specified items as read, or only reports them when running in dry-run mode.
+// Protected items are always skipped.
+func (c *Cleaner) markItems(ctx context.Context, log *slog.Logger, feedID string, authToken string, items []client.Item, result *FeedResult) error {
+	items, spared := c.withoutProtectedItems(items)
+	if spared > 0 {
+		log.Info("Skipping protected items", "feed_id", feedID, "count", spared)
+		result.Spared += spared
+	}
+
+	if c.dryRun {
+		reportItems(log, feedID, items)
+		result.Marked += len(items)
+
+		if result.reported == nil {
+			result.reported = make(map[string]bool, len(items))
+		}
+		for _, item := range items {
+			result.reported[item.ID] = true
+		}
+		return nil
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+
+	// Items are recorded before being marked, so a failure in the middle can still be undone
+	if c.journal != nil {
+		if err := c.journal.Record(result.runID, feedID, ids); err != nil {
+			return fmt.Errorf("failed to record items in journal: %w", err)
 		}
+	}
 
+	log.Info("Marking items as read", "feed_id", feedID, "count", len(ids))
+
+	if err := c.client.EditTag(ctx, authToken, ids, []string{client.StateRead}, nil); err != nil {
+		return err
 	}
 
+	result.Marked += len(ids)
+
 	return nil
 }
 
-func (c *Cleaner) processFeed(ctx context.Context, feed config.FeedConfig, authToken string) error {
-	return c.client.MarkAsRead(ctx, authToken, feed.ID, feed.Days)
+// newRunID returns a new ID for the run started at the specified time, sorting in the order the runs were started (ex: "20240102-150405-1a2b")
+func newRunID(start time.Time) string {
+	return fmt.Sprintf("%s-%04x", start.UTC().Format("20060102-150405"), rand.N(0x10000))
+}
+
+// reportItems logs the items that would be marked as read when running in dry-run mode
+func reportItems(log *slog.Logger, feedID string, items []client.Item) {
+	log.Info("Dry run: items that would be marked as read", "feed_id", feedID, "count", len(items))
+	for _, item := range items {
+		log.Info("Would mark as read", "feed_id", feedID, "title", item.Title, "published", item.Published.Format(time.RFC3339))
+	}
+}
+
+// withoutReportedItems removes the items already reported in dry-run mode from the list
+func withoutReportedItems(items []client.Item, reported map[string]bool) []client.Item {
+	if len(reported) == 0 {
+		return items
+	}
+
+	remaining := make([]client.Item, 0, len(items))
+	for _, item := range items {
+		if !reported[item.ID] {
+			remaining = append(remaining, item)
+		}
+	}
+
+	return remaining
+}
+
+// unreadItems pages through the stream contents and returns all the unread items matching the options
+func (c *Cleaner) unreadItems(ctx context.Context, authToken string, streamID string, opts client.StreamOptions) ([]client.Item, error) {
+	opts.Count = streamPageSize
+	opts.ExcludeTarget = client.StateRead
+
+	var items []client.Item
+	for {
+		page, err := c.client.StreamContents(ctx, authToken, streamID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch stream contents: %w", err)
+		}
+
+		items = append(items, page.Items...)
+
+		if page.Continuation == "" || len(page.Items) == 0 {
+			return items, nil
+		}
+		opts.Continuation = page.Continuation
+	}
 }
@@ -2,15 +2,19 @@ package freshrss_test
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/brpaz/freshrss-cleaner/internal/config"
 	"github.com/brpaz/freshrss-cleaner/internal/freshrss"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
 )
 
 // mockClient implements a mock of the client interface for testing
@@ -23,22 +27,108 @@ func (m *mockClient) GetAuthToken(ctx context.Context) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
-func (m *mockClient) MarkAsRead(ctx context.Context, authToken string, feedID string, days int) error {
-	args := m.Called(ctx, authToken, feedID, days)
+func (m *mockClient) MarkAsRead(ctx context.Context, authToken string, feedID string, olderThan time.Time) error {
+	args := m.Called(ctx, authToken, feedID, olderThan)
 	return args.Error(0)
 }
 
+func (m *mockClient) StreamContents(ctx context.Context, authToken string, streamID string, opts client.StreamOptions) (*client.StreamContents, error) {
+	args := m.Called(ctx, authToken, streamID, opts)
+	contents, _ := args.Get(0).(*client.StreamContents)
+	return contents, args.Error(1)
+}
+
+func (m *mockClient) EditTag(ctx context.Context, authToken string, itemIDs []string, add []string, remove []string) error {
+	args := m.Called(ctx, authToken, itemIDs, add, remove)
+	return args.Error(0)
+}
+
+func (m *mockClient) UnreadCounts(ctx context.Context, authToken string) (map[string]int, error) {
+	args := m.Called(ctx, authToken)
+	counts, _ := args.Get(0).(map[string]int)
+	return counts, args.Error(1)
+}
+
+func (m *mockClient) SubscriptionList(ctx context.Context, authToken string) ([]client.Subscription, error) {
+	args := m.Called(ctx, authToken)
+	subscriptions, _ := args.Get(0).([]client.Subscription)
+	return subscriptions, args.Error(1)
+}
+
+func (m *mockClient) TagList(ctx context.Context, authToken string) ([]client.Tag, error) {
+	args := m.Called(ctx, authToken)
+	tags, _ := args.Get(0).([]client.Tag)
+	return tags, args.Error(1)
+}
+
+// mockObserver implements a mock of the cleaner observer for testing
+type mockObserver struct {
+	mock.Mock
+}
+
+func (m *mockObserver) ObserveAuthFailure() {
+	m.Called()
+}
+
+func (m *mockObserver) ObserveFeedResult(result freshrss.FeedResult) {
+	m.Called(result)
+}
+
+func (m *mockObserver) ObserveRuleResult(rule string, succeeded bool) {
+	m.Called(rule, succeeded)
+}
+
+// mockTokenStore implements a mock of the token store for testing
+type mockTokenStore struct {
+	mock.Mock
+}
+
+func (m *mockTokenStore) Load() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockTokenStore) Save(token string) error {
+	return m.Called(token).Error(0)
+}
+
+func (m *mockTokenStore) Clear() error {
+	return m.Called().Error(0)
+}
+
+// mockJournal implements a mock of the journal for testing
+type mockJournal struct {
+	mock.Mock
+}
+
+func (m *mockJournal) Record(runID string, feedID string, itemIDs []string) error {
+	return m.Called(runID, feedID, itemIDs).Error(0)
+}
+
 // Test fixtures
+var disabled = false
+
+// mockConfig disables the protection of starred items, so age rules use the mark-all-as-read API
 var mockConfig = &config.RootConfig{
 	URL:      "https://example.com",
 	Username: "user",
 	Password: "pass",
+	Protect:  config.ProtectConfig{Starred: &disabled},
 	Feeds: []config.FeedConfig{
 		{ID: "feed1", Days: 7},
 		{ID: "feed2", Days: 14},
+		{ID: "feed3", OlderThan: config.Duration(6 * time.Hour)},
 	},
 }
 
+// cutoffAround matches a cutoff time that is close to the expected age from now
+func cutoffAround(age time.Duration) any {
+	return mock.MatchedBy(func(cutoff time.Time) bool {
+		expected := time.Now().Add(-age)
+		return cutoff.After(expected.Add(-time.Minute)) && cutoff.Before(expected.Add(time.Minute))
+	})
+}
+
 func TestNewCleaner(t *testing.T) {
 	t.Parallel()
 
@@ -110,10 +200,12 @@ func TestCleanOldEntries(t *testing.T) {
 		assert.Nil(t, err)
 
 		client.On("GetAuthToken", ctx).Return("mockToken", nil)
-		client.On("MarkAsRead", ctx, "mockToken", "feed1", 7).Return(nil)
-		client.On("MarkAsRead", ctx, "mockToken", "feed2", 14).Return(nil)
+		client.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+		client.On("MarkAsRead", ctx, "mockToken", "feed1", cutoffAround(7*24*time.Hour)).Return(nil)
+		client.On("MarkAsRead", ctx, "mockToken", "feed2", cutoffAround(14*24*time.Hour)).Return(nil)
+		client.On("MarkAsRead", ctx, "mockToken", "feed3", cutoffAround(6*time.Hour)).Return(nil)
 
-		err = cleaner.CleanOldEntries(ctx, logger)
+		_, err = cleaner.CleanOldEntries(ctx, logger)
 		assert.Nil(t, err)
 
 		client.AssertExpectations(t)
@@ -132,10 +224,882 @@ func TestCleanOldEntries(t *testing.T) {
 
 		client.On("GetAuthToken", ctx).Return("", assert.AnError)
 
-		err = cleaner.CleanOldEntries(ctx, logger)
+		_, err = cleaner.CleanOldEntries(ctx, logger)
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "failed to get auth token")
+		assert.ErrorIs(t, err, freshrss.ErrAuthentication)
 
 		client.AssertExpectations(t)
 	})
 }
+
+func TestCleanOldEntries_DryRun(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	t.Run("Does not mark items as read", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(
+			freshrss.WithClient(c),
+			freshrss.WithConfig(mockConfig),
+			freshrss.WithDryRun(true),
+		)
+		assert.Nil(t, err)
+
+		unreadOpts := mock.MatchedBy(func(opts client.StreamOptions) bool {
+			return opts.ExcludeTarget == client.StateRead && !opts.OlderThan.IsZero() && opts.Continuation == ""
+		})
+		nextPageOpts := mock.MatchedBy(func(opts client.StreamOptions) bool {
+			return opts.Continuation == "page2"
+		})
+
+		c.On("GetAuthToken", ctx).Return("mockToken", nil)
+		c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+		c.On("StreamContents", ctx, "mockToken", "feed1", unreadOpts).Return(&client.StreamContents{
+			Items:        []client.Item{{ID: "1", Title: "First", Published: time.Now()}},
+			Continuation: "page2",
+		}, nil)
+		c.On("StreamContents", ctx, "mockToken", "feed1", nextPageOpts).Return(&client.StreamContents{
+			Items: []client.Item{{ID: "2", Title: "Second", Published: time.Now()}},
+		}, nil)
+		c.On("StreamContents", ctx, "mockToken", "feed2", unreadOpts).Return(&client.StreamContents{}, nil)
+		c.On("StreamContents", ctx, "mockToken", "feed3", unreadOpts).Return(&client.StreamContents{}, nil)
+
+		_, err = cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+
+		c.AssertExpectations(t)
+		c.AssertNotCalled(t, "MarkAsRead", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCleanOldEntries_KeepUnread(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	unreadItems := &client.StreamContents{
+		Items: []client.Item{{ID: "4"}, {ID: "3"}, {ID: "2"}, {ID: "1"}},
+	}
+
+	t.Run("Marks items beyond the newest N as read", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(
+			freshrss.WithClient(c),
+			freshrss.WithConfig(&config.RootConfig{
+				Feeds: []config.FeedConfig{{ID: "feed1", KeepUnread: 2}},
+			}),
+		)
+		assert.Nil(t, err)
+
+		c.On("GetAuthToken", ctx).Return("mockToken", nil)
+		c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+		c.On("StreamContents", ctx, "mockToken", "feed1", mock.MatchedBy(func(opts client.StreamOptions) bool {
+			return opts.ExcludeTarget == client.StateRead && opts.NewerThan.IsZero() && opts.OlderThan.IsZero()
+		})).Return(unreadItems, nil)
+		c.On("EditTag", ctx, "mockToken", []string{"2", "1"}, []string{client.StateRead}, []string(nil)).Return(nil)
+
+		_, err = cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+
+		c.AssertExpectations(t)
+		c.AssertNotCalled(t, "MarkAsRead", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Combines the age and the unread limit", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(
+			freshrss.WithClient(c),
+			freshrss.WithConfig(&config.RootConfig{
+				Protect: config.ProtectConfig{Starred: &disabled},
+				Feeds:   []config.FeedConfig{{ID: "feed1", Days: 7, KeepUnread: 3}},
+			}),
+		)
+		assert.Nil(t, err)
+
+		c.On("GetAuthToken", ctx).Return("mockToken", nil)
+		c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+		c.On("MarkAsRead", ctx, "mockToken", "feed1", cutoffAround(7*24*time.Hour)).Return(nil)
+		c.On("StreamContents", ctx, "mockToken", "feed1", mock.MatchedBy(func(opts client.StreamOptions) bool {
+			return !opts.NewerThan.IsZero()
+		})).Return(unreadItems, nil)
+		c.On("EditTag", ctx, "mockToken", []string{"1"}, []string{client.StateRead}, []string(nil)).Return(nil)
+
+		_, err = cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+
+		c.AssertExpectations(t)
+	})
+
+	t.Run("Doesn't count the items selected by the filters twice in dry-run mode", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(
+			freshrss.WithClient(c),
+			freshrss.WithDryRun(true),
+			freshrss.WithConfig(&config.RootConfig{
+				Feeds: []config.FeedConfig{{
+					ID:         "feed1",
+					Match:      &config.ItemFilter{Title: "^Sponsored:"},
+					Exclude:    &config.ItemFilter{Author: "Jane"},
+					KeepUnread: 1,
+				}},
+			}),
+		)
+		assert.Nil(t, err)
+
+		c.On("GetAuthToken", ctx).Return("mockToken", nil)
+		c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+		c.On("StreamContents", ctx, "mockToken", "feed1", mock.Anything).Return(&client.StreamContents{
+			Items: []client.Item{
+				{ID: "4", Title: "Sponsored: Buy this", Author: "Ads"},
+				{ID: "3", Title: "Breaking news", Author: "Jane Doe"},
+				{ID: "2", Title: "Sponsored: Keep this", Author: "Jane Doe"},
+				{ID: "1", Title: "Old news", Author: "Jane Doe"},
+			},
+		}, nil)
+
+		result, err := cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+
+		// Item 4 is selected by the filters, then items 2 and 1 are beyond the newest unread item left
+		assert.Equal(t, 3, result.Feeds[0].Marked)
+		c.AssertNotCalled(t, "EditTag", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Does nothing when under the unread limit", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(
+			freshrss.WithClient(c),
+			freshrss.WithConfig(&config.RootConfig{
+				Feeds: []config.FeedConfig{{ID: "feed1", KeepUnread: 10}},
+			}),
+		)
+		assert.Nil(t, err)
+
+		c.On("GetAuthToken", ctx).Return("mockToken", nil)
+		c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+		c.On("StreamContents", ctx, "mockToken", "feed1", mock.Anything).Return(unreadItems, nil)
+
+		_, err = cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+
+		c.AssertExpectations(t)
+		c.AssertNotCalled(t, "EditTag", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCleanOldEntries_Filters(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	unreadItems := &client.StreamContents{
+		Items: []client.Item{
+			{ID: "1", Title: "Sponsored: Buy this", Author: "Ads", URL: "https://news.example.com/ads/1"},
+			{ID: "2", Title: "Breaking news", Author: "Jane Doe", URL: "https://news.example.com/world/2"},
+			{ID: "3", Title: "Sponsored: Keep this", Author: "Jane Doe", URL: "https://news.example.com/ads/3", Content: "<p>Partner content</p>"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		feed        config.FeedConfig
+		expectedIDs []string
+	}{
+		{
+			name:        "WithMatchRule",
+			feed:        config.FeedConfig{ID: "feed1", Match: &config.ItemFilter{Title: "^Sponsored:"}},
+			expectedIDs: []string{"1", "3"},
+		},
+		{
+			name:        "WithMultipleMatchFields_RequiresAllToMatch",
+			feed:        config.FeedConfig{ID: "feed1", Match: &config.ItemFilter{Title: "^Sponsored:", Author: "Jane"}},
+			expectedIDs: []string{"3"},
+		},
+		{
+			name: "WithMatchAndExcludeRules",
+			feed: config.FeedConfig{
+				ID:      "feed1",
+				Match:   &config.ItemFilter{URL: "/ads/"},
+				Exclude: &config.ItemFilter{Content: "Partner"},
+			},
+			expectedIDs: []string{"1"},
+		},
+		{
+			name:        "WithExcludeRuleOnly",
+			feed:        config.FeedConfig{ID: "feed1", Exclude: &config.ItemFilter{Author: "Ads"}},
+			expectedIDs: []string{"2", "3"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := &mockClient{}
+			ctx := context.Background()
+
+			cleaner, err := freshrss.NewCleaner(
+				freshrss.WithClient(c),
+				freshrss.WithConfig(&config.RootConfig{Feeds: []config.FeedConfig{tc.feed}}),
+			)
+			assert.Nil(t, err)
+
+			c.On("GetAuthToken", ctx).Return("mockToken", nil)
+			c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+			c.On("StreamContents", ctx, "mockToken", "feed1", mock.MatchedBy(func(opts client.StreamOptions) bool {
+				return opts.ExcludeTarget == client.StateRead && opts.OlderThan.IsZero()
+			})).Return(unreadItems, nil)
+			c.On("EditTag", ctx, "mockToken", tc.expectedIDs, []string{client.StateRead}, []string(nil)).Return(nil)
+
+			_, err = cleaner.CleanOldEntries(ctx, logger)
+			assert.Nil(t, err)
+
+			c.AssertExpectations(t)
+			c.AssertNotCalled(t, "MarkAsRead", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("WithMaxAge_OnlyConsidersOlderItems", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(
+			freshrss.WithClient(c),
+			freshrss.WithConfig(&config.RootConfig{
+				Feeds: []config.FeedConfig{{ID: "feed1", Days: 7, Match: &config.ItemFilter{Title: "^Breaking"}}},
+			}),
+		)
+		assert.Nil(t, err)
+
+		c.On("GetAuthToken", ctx).Return("mockToken", nil)
+		c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+		c.On("StreamContents", ctx, "mockToken", "feed1", mock.MatchedBy(func(opts client.StreamOptions) bool {
+			return !opts.OlderThan.IsZero()
+		})).Return(unreadItems, nil)
+		c.On("EditTag", ctx, "mockToken", []string{"2"}, []string{client.StateRead}, []string(nil)).Return(nil)
+
+		_, err = cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+
+		c.AssertExpectations(t)
+	})
+
+	t.Run("WithInvalidRegex_SkipsFeed", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(
+			freshrss.WithClient(c),
+			freshrss.WithConfig(&config.RootConfig{
+				Feeds: []config.FeedConfig{{ID: "feed1", Match: &config.ItemFilter{Title: "(["}}},
+			}),
+		)
+		assert.Nil(t, err)
+
+		c.On("GetAuthToken", ctx).Return("mockToken", nil)
+		c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+
+		_, err = cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+
+		c.AssertExpectations(t)
+		c.AssertNotCalled(t, "StreamContents", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCleanOldEntries_ProtectedItems(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	unreadItems := &client.StreamContents{
+		Items: []client.Item{
+			{ID: "1", Categories: []string{client.StateReadingList}},
+			{ID: "2", Categories: []string{client.StateReadingList, client.StateStarred}},
+			{ID: "3", Categories: []string{client.StateReadingList, "user/-/label/Later"}},
+			{ID: "4", Categories: []string{client.StateReadingList, "user/-/label/Keep"}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		protect     config.ProtectConfig
+		feed        config.FeedConfig
+		expectedIDs []string
+	}{
+		{
+			name:        "ProtectsStarredItemsByDefault",
+			feed:        config.FeedConfig{ID: "feed1", Days: 7},
+			expectedIDs: []string{"1", "3", "4"},
+		},
+		{
+			name:        "ProtectsConfiguredLabels",
+			protect:     config.ProtectConfig{Labels: []string{"Later", "user/-/label/Keep"}},
+			feed:        config.FeedConfig{ID: "feed1", Days: 7},
+			expectedIDs: []string{"1"},
+		},
+		{
+			name:        "ProtectsItemsMatchingFilters",
+			protect:     config.ProtectConfig{Starred: &disabled, Labels: []string{"Later"}},
+			feed:        config.FeedConfig{ID: "feed1", Match: &config.ItemFilter{Title: ".*"}},
+			expectedIDs: []string{"1", "2", "4"},
+		},
+		{
+			name:        "ProtectsItemsExceedingTheUnreadLimit",
+			feed:        config.FeedConfig{ID: "feed1", KeepUnread: 1},
+			expectedIDs: []string{"3", "4"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := &mockClient{}
+			ctx := context.Background()
+
+			cleaner, err := freshrss.NewCleaner(
+				freshrss.WithClient(c),
+				freshrss.WithConfig(&config.RootConfig{
+					Protect: tc.protect,
+					Feeds:   []config.FeedConfig{tc.feed},
+				}),
+			)
+			assert.Nil(t, err)
+
+			c.On("GetAuthToken", ctx).Return("mockToken", nil)
+			c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+			c.On("StreamContents", ctx, "mockToken", "feed1", mock.Anything).Return(unreadItems, nil)
+			c.On("EditTag", ctx, "mockToken", tc.expectedIDs, []string{client.StateRead}, []string(nil)).Return(nil)
+
+			_, err = cleaner.CleanOldEntries(ctx, logger)
+			assert.Nil(t, err)
+
+			c.AssertExpectations(t)
+			c.AssertNotCalled(t, "MarkAsRead", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCleanOldEntries_Result(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	c := &mockClient{}
+	ctx := context.Background()
+
+	cleaner, err := freshrss.NewCleaner(
+		freshrss.WithClient(c),
+		freshrss.WithConfig(&config.RootConfig{
+			Protect: config.ProtectConfig{Starred: &disabled},
+			Feeds: []config.FeedConfig{
+				{ID: "feed1", Days: 7},
+				{ID: "feed2", Days: 14},
+				{ID: "feed3", KeepUnread: 1},
+			},
+		}),
+	)
+	assert.Nil(t, err)
+
+	c.On("GetAuthToken", ctx).Return("mockToken", nil)
+	c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{"feed1": 10, "feed2": 5, "feed3": 2}, nil).Once()
+	c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{"feed1": 4, "feed2": 5, "feed3": 1}, nil).Once()
+	c.On("MarkAsRead", ctx, "mockToken", "feed1", mock.Anything).Return(nil)
+	c.On("MarkAsRead", ctx, "mockToken", "feed2", mock.Anything).Return(assert.AnError)
+	c.On("StreamContents", ctx, "mockToken", "feed3", mock.Anything).Return(&client.StreamContents{
+		Items: []client.Item{{ID: "2"}, {ID: "1"}},
+	}, nil)
+	c.On("EditTag", ctx, "mockToken", []string{"1"}, []string{client.StateRead}, []string(nil)).Return(nil)
+
+	result, err := cleaner.CleanOldEntries(ctx, logger)
+	assert.Nil(t, err)
+	c.AssertExpectations(t)
+
+	assert.Len(t, result.Feeds, 3)
+	assert.Equal(t, 1, result.Failed())
+	assert.Equal(t, 7, result.Marked())
+
+	assert.Equal(t, "feed1", result.Feeds[0].FeedID)
+	assert.Equal(t, "days=7", result.Feeds[0].Rule)
+	assert.Equal(t, 10, result.Feeds[0].UnreadBefore)
+	assert.Equal(t, 4, result.Feeds[0].UnreadAfter)
+	assert.Equal(t, 6, result.Feeds[0].Marked)
+	assert.NoError(t, result.Feeds[0].Err)
+
+	assert.Equal(t, "feed2", result.Feeds[1].FeedID)
+	assert.ErrorIs(t, result.Feeds[1].Err, assert.AnError)
+	assert.Equal(t, 0, result.Feeds[1].Marked)
+
+	assert.Equal(t, "keep_unread=1", result.Feeds[2].Rule)
+	assert.Equal(t, 1, result.Feeds[2].Marked)
+}
+
+func TestCleanOldEntries_FailurePolicy(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	tests := []struct {
+		policy            config.FailurePolicy
+		expectedProcessed int
+	}{
+		{policy: config.FailurePolicyContinue, expectedProcessed: 3},
+		{policy: config.FailurePolicyFailAtEnd, expectedProcessed: 3},
+		{policy: "", expectedProcessed: 3},
+		{policy: config.FailurePolicyFailFast, expectedProcessed: 2},
+	}
+
+	for _, tc := range tests {
+		tc := tc // capture range variable
+		t.Run(string(tc.policy), func(t *testing.T) {
+			t.Parallel()
+			c := &mockClient{}
+			ctx := context.Background()
+
+			cleaner, err := freshrss.NewCleaner(
+				freshrss.WithClient(c),
+				freshrss.WithConfig(&config.RootConfig{
+					FailurePolicy: tc.policy,
+					Protect:       config.ProtectConfig{Starred: &disabled},
+					Feeds:         mockConfig.Feeds,
+				}),
+			)
+			assert.Nil(t, err)
+
+			c.On("GetAuthToken", ctx).Return("mockToken", nil)
+			c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+			c.On("MarkAsRead", ctx, "mockToken", "feed1", mock.Anything).Return(nil)
+			c.On("MarkAsRead", ctx, "mockToken", "feed2", mock.Anything).Return(assert.AnError)
+			if tc.expectedProcessed == 3 {
+				c.On("MarkAsRead", ctx, "mockToken", "feed3", mock.Anything).Return(nil)
+			}
+
+			result, err := cleaner.CleanOldEntries(ctx, logger)
+			assert.Nil(t, err)
+			assert.Len(t, result.Feeds, tc.expectedProcessed)
+			assert.Equal(t, 1, result.Failed())
+
+			c.AssertExpectations(t)
+			c.AssertNumberOfCalls(t, "MarkAsRead", tc.expectedProcessed)
+		})
+	}
+}
+
+func TestCleanOldEntries_AuthToken(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cfg := &config.RootConfig{
+		Protect: config.ProtectConfig{Starred: &disabled},
+		Feeds:   []config.FeedConfig{{ID: "feed1", Days: 7}},
+	}
+
+	t.Run("Reuses the auth token between runs", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(freshrss.WithClient(c), freshrss.WithConfig(cfg))
+		assert.Nil(t, err)
+
+		c.On("GetAuthToken", ctx).Return("mockToken", nil).Once()
+		c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+		c.On("MarkAsRead", ctx, "mockToken", "feed1", mock.Anything).Return(nil).Twice()
+
+		_, err = cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+		_, err = cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+
+		c.AssertExpectations(t)
+	})
+
+	t.Run("Logs in again when the auth token is rejected", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(freshrss.WithClient(c), freshrss.WithConfig(cfg))
+		assert.Nil(t, err)
+
+		c.On("GetAuthToken", ctx).Return("expiredToken", nil).Once()
+		c.On("GetAuthToken", ctx).Return("newToken", nil).Once()
+		c.On("UnreadCounts", ctx, mock.Anything).Return(map[string]int{}, nil)
+		c.On("MarkAsRead", ctx, "expiredToken", "feed1", mock.Anything).Return(&client.StatusError{
+			Request:    "mark-as-read",
+			StatusCode: 401,
+		}).Once()
+		c.On("MarkAsRead", ctx, "newToken", "feed1", mock.Anything).Return(nil).Once()
+
+		result, err := cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, result.Failed())
+
+		c.AssertExpectations(t)
+	})
+
+	t.Run("Returns an authentication error when logging in again fails", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(freshrss.WithClient(c), freshrss.WithConfig(cfg))
+		assert.Nil(t, err)
+
+		c.On("GetAuthToken", ctx).Return("expiredToken", nil).Once()
+		c.On("GetAuthToken", ctx).Return("", assert.AnError).Once()
+		c.On("UnreadCounts", ctx, mock.Anything).Return(map[string]int{}, nil)
+		c.On("MarkAsRead", ctx, "expiredToken", "feed1", mock.Anything).Return(&client.StatusError{StatusCode: 401}).Once()
+
+		_, err = cleaner.CleanOldEntries(ctx, logger)
+		assert.ErrorIs(t, err, freshrss.ErrAuthentication)
+
+		c.AssertExpectations(t)
+	})
+}
+
+func TestCleanOldEntries_Observer(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cfg := &config.RootConfig{
+		Protect: config.ProtectConfig{Starred: &disabled},
+		Feeds:   []config.FeedConfig{{ID: "feed1", Days: 7}},
+	}
+
+	t.Run("Observes the result of every feed", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		o := &mockObserver{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(freshrss.WithClient(c), freshrss.WithConfig(cfg), freshrss.WithObserver(o))
+		assert.Nil(t, err)
+
+		c.On("GetAuthToken", ctx).Return("mockToken", nil)
+		c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{"feed1": 10}, nil).Once()
+		c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{"feed1": 3}, nil).Once()
+		c.On("MarkAsRead", ctx, "mockToken", "feed1", mock.Anything).Return(nil)
+		o.On("ObserveFeedResult", mock.MatchedBy(func(result freshrss.FeedResult) bool {
+			return result.FeedID == "feed1" && result.Marked == 7 && result.Err == nil
+		})).Once()
+		o.On("ObserveRuleResult", "feed1", true).Once()
+
+		_, err = cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+
+		c.AssertExpectations(t)
+		o.AssertExpectations(t)
+	})
+
+	t.Run("Observes the outcome of every rule", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		o := &mockObserver{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(freshrss.WithClient(c), freshrss.WithObserver(o), freshrss.WithConfig(&config.RootConfig{
+			Protect: config.ProtectConfig{Starred: &disabled},
+			Feeds: []config.FeedConfig{
+				{FeedsMatching: "*news*", Days: 7},
+				{FeedsMatching: "Go*", ExcludeFeeds: []string{"*"}, Days: 7},
+				{ID: "feed/9", Days: 7},
+			},
+		}))
+		assert.Nil(t, err)
+
+		c.On("GetAuthToken", ctx).Return("mockToken", nil)
+		c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+		c.On("SubscriptionList", ctx, "mockToken").Return(mockCatalog.Subscriptions, nil).Once()
+		c.On("TagList", ctx, "mockToken").Return(mockCatalog.Tags, nil).Once()
+		c.On("MarkAsRead", ctx, "mockToken", "feed/9", mock.Anything).Return(assert.AnError)
+		c.On("MarkAsRead", ctx, "mockToken", mock.Anything, mock.Anything).Return(nil)
+		o.On("ObserveFeedResult", mock.Anything).Times(4)
+		// The pattern rule is split into a feed per subscription, and the rule excluding every feed still succeeds
+		o.On("ObserveRuleResult", `feeds_matching="*news*"`, true).Once()
+		o.On("ObserveRuleResult", `feeds_matching="Go*"`, true).Once()
+		o.On("ObserveRuleResult", "feed/9", false).Once()
+
+		_, err = cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+
+		c.AssertExpectations(t)
+		o.AssertExpectations(t)
+	})
+
+	t.Run("Observes authentication failures", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		o := &mockObserver{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(freshrss.WithClient(c), freshrss.WithConfig(cfg), freshrss.WithObserver(o))
+		assert.Nil(t, err)
+
+		c.On("GetAuthToken", ctx).Return("", assert.AnError)
+		o.On("ObserveAuthFailure").Once()
+
+		_, err = cleaner.CleanOldEntries(ctx, logger)
+		assert.ErrorIs(t, err, freshrss.ErrAuthentication)
+
+		c.AssertExpectations(t)
+		o.AssertExpectations(t)
+	})
+}
+
+func TestCleanOldEntries_TokenStore(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cfg := &config.RootConfig{
+		Protect: config.ProtectConfig{Starred: &disabled},
+		Feeds:   []config.FeedConfig{{ID: "feed1", Days: 7}},
+	}
+
+	t.Run("Reuses the stored auth token", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		store := &mockTokenStore{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(freshrss.WithClient(c), freshrss.WithConfig(cfg), freshrss.WithTokenStore(store))
+		assert.Nil(t, err)
+
+		store.On("Load").Return("cachedToken", nil)
+		c.On("UnreadCounts", ctx, "cachedToken").Return(map[string]int{}, nil)
+		c.On("MarkAsRead", ctx, "cachedToken", "feed1", mock.Anything).Return(nil)
+
+		_, err = cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+
+		c.AssertExpectations(t)
+		c.AssertNotCalled(t, "GetAuthToken", mock.Anything)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Stores the auth token after logging in", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		store := &mockTokenStore{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(freshrss.WithClient(c), freshrss.WithConfig(cfg), freshrss.WithTokenStore(store))
+		assert.Nil(t, err)
+
+		store.On("Load").Return("", nil)
+		store.On("Save", "newToken").Return(nil).Once()
+		c.On("GetAuthToken", ctx).Return("newToken", nil).Once()
+		c.On("UnreadCounts", ctx, "newToken").Return(map[string]int{}, nil)
+		c.On("MarkAsRead", ctx, "newToken", "feed1", mock.Anything).Return(nil)
+
+		_, err = cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+
+		c.AssertExpectations(t)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("Logs in again when the stored auth token is rejected", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		store := &mockTokenStore{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(freshrss.WithClient(c), freshrss.WithConfig(cfg), freshrss.WithTokenStore(store))
+		assert.Nil(t, err)
+
+		store.On("Load").Return("expiredToken", nil).Once()
+		store.On("Clear").Return(nil).Once()
+		store.On("Save", "newToken").Return(nil).Once()
+		c.On("UnreadCounts", ctx, "expiredToken").Return(nil, &client.StatusError{StatusCode: 401}).Once()
+		c.On("GetAuthToken", ctx).Return("newToken", nil).Once()
+		c.On("UnreadCounts", ctx, "newToken").Return(map[string]int{"feed1": 3}, nil)
+		c.On("MarkAsRead", ctx, "newToken", "feed1", mock.Anything).Return(nil)
+
+		result, err := cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, result.Feeds[0].UnreadBefore)
+
+		c.AssertExpectations(t)
+		store.AssertExpectations(t)
+	})
+}
+
+func TestCleanOldEntries_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	c := &mockClient{}
+	ctx := context.Background()
+
+	feeds := make([]config.FeedConfig, 10)
+	for i := range feeds {
+		feeds[i] = config.FeedConfig{ID: fmt.Sprintf("feed%d", i), Days: 7}
+	}
+
+	cleaner, err := freshrss.NewCleaner(
+		freshrss.WithClient(c),
+		freshrss.WithConfig(&config.RootConfig{
+			Concurrency: 4,
+			Protect:     config.ProtectConfig{Starred: &disabled},
+			Feeds:       feeds,
+		}),
+	)
+	assert.Nil(t, err)
+
+	// Each feed blocks for a while, so the workers overlap and the feeds in flight can be counted
+	var inFlight, maxInFlight atomic.Int32
+	c.On("GetAuthToken", ctx).Return("mockToken", nil).Once()
+	c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+	c.On("MarkAsRead", ctx, "mockToken", mock.Anything, mock.Anything).Return(nil).Times(len(feeds)).Run(func(mock.Arguments) {
+		n := inFlight.Add(1)
+		for {
+			current := maxInFlight.Load()
+			if n <= current || maxInFlight.CompareAndSwap(current, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		inFlight.Add(-1)
+	})
+
+	result, err := cleaner.CleanOldEntries(ctx, logger)
+	assert.Nil(t, err)
+	c.AssertExpectations(t)
+
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(4), "no more feeds than the concurrency must run at once")
+	assert.Greater(t, maxInFlight.Load(), int32(1), "feeds must run concurrently")
+
+	assert.Len(t, result.Feeds, len(feeds))
+	for i, feedResult := range result.Feeds {
+		assert.Equal(t, feeds[i].ID, feedResult.FeedID, "results must keep the order of the feeds")
+	}
+}
+
+func TestCleanOldEntries_Cancelled(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	c := &mockClient{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	feeds := make([]config.FeedConfig, 5)
+	for i := range feeds {
+		feeds[i] = config.FeedConfig{ID: fmt.Sprintf("feed%d", i), Days: 7}
+	}
+
+	cleaner, err := freshrss.NewCleaner(
+		freshrss.WithClient(c),
+		freshrss.WithConfig(&config.RootConfig{
+			Concurrency: 1,
+			Protect:     config.ProtectConfig{Starred: &disabled},
+			Feeds:       feeds,
+		}),
+	)
+	assert.Nil(t, err)
+
+	// The run is cancelled while the first feed is processed
+	c.On("GetAuthToken", ctx).Return("mockToken", nil).Once()
+	c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+	c.On("MarkAsRead", ctx, "mockToken", "feed0", mock.Anything).Return(nil).Once().Run(func(mock.Arguments) {
+		cancel()
+	})
+
+	result, err := cleaner.CleanOldEntries(ctx, logger)
+	assert.Nil(t, err)
+	c.AssertExpectations(t)
+	c.AssertNumberOfCalls(t, "MarkAsRead", 1)
+
+	assert.Len(t, result.Feeds, len(feeds))
+	assert.NoError(t, result.Feeds[0].Err)
+	for i, feedResult := range result.Feeds[1:] {
+		assert.Equal(t, feeds[i+1].ID, feedResult.FeedID)
+		assert.ErrorIs(t, feedResult.Err, context.Canceled)
+	}
+}
+
+func TestCleanOldEntries_Journal(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cfg := &config.RootConfig{
+		Protect: config.ProtectConfig{Starred: &disabled},
+		Feeds:   []config.FeedConfig{{ID: "feed1", Days: 7}},
+	}
+	oldItems := &client.StreamContents{Items: []client.Item{{ID: "2"}, {ID: "1"}}}
+
+	t.Run("Records the items before marking them as read", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		j := &mockJournal{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(freshrss.WithClient(c), freshrss.WithConfig(cfg), freshrss.WithJournal(j))
+		assert.Nil(t, err)
+
+		var runID string
+		c.On("GetAuthToken", ctx).Return("mockToken", nil)
+		c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+		c.On("StreamContents", ctx, "mockToken", "feed1", mock.MatchedBy(func(opts client.StreamOptions) bool {
+			return opts.ExcludeTarget == client.StateRead && !opts.OlderThan.IsZero()
+		})).Return(oldItems, nil)
+		j.On("Record", mock.AnythingOfType("string"), "feed1", []string{"2", "1"}).Run(func(args mock.Arguments) {
+			runID = args.String(0)
+		}).Return(nil).Once()
+		c.On("EditTag", ctx, "mockToken", []string{"2", "1"}, []string{client.StateRead}, []string(nil)).Return(nil).Once()
+
+		result, err := cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+
+		c.AssertExpectations(t)
+		j.AssertExpectations(t)
+		c.AssertNotCalled(t, "MarkAsRead", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		assert.NotEmpty(t, result.RunID)
+		assert.Equal(t, result.RunID, runID)
+		assert.Equal(t, 2, result.Marked())
+	})
+
+	t.Run("Doesn't mark the items when they can't be recorded", func(t *testing.T) {
+		t.Parallel()
+		c := &mockClient{}
+		j := &mockJournal{}
+		ctx := context.Background()
+
+		cleaner, err := freshrss.NewCleaner(freshrss.WithClient(c), freshrss.WithConfig(cfg), freshrss.WithJournal(j))
+		assert.Nil(t, err)
+
+		c.On("GetAuthToken", ctx).Return("mockToken", nil)
+		c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+		c.On("StreamContents", ctx, "mockToken", "feed1", mock.Anything).Return(oldItems, nil)
+		j.On("Record", mock.Anything, "feed1", []string{"2", "1"}).Return(fmt.Errorf("disk full"))
+
+		result, err := cleaner.CleanOldEntries(ctx, logger)
+		assert.Nil(t, err)
+
+		c.AssertNotCalled(t, "EditTag", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		if assert.Len(t, result.Feeds, 1) {
+			assert.ErrorContains(t, result.Feeds[0].Err, "failed to record items in journal: disk full")
+		}
+	})
+}
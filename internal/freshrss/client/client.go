@@ -4,22 +4,47 @@ package client
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 // Client represents a client for the FreshRSS API
 type Client struct {
-	baseURL    string
-	username   string
-	password   string
-	httpClient *http.Client
+	baseURL          string
+	username         string
+	password         string
+	authToken        string
+	httpClient       *http.Client
+	editTagBatchSize int
+	observer         RequestObserver
+	retryPolicy      RetryPolicy
+	limiter          *rate.Limiter
+	logger           *slog.Logger
+
+	// actionToken is the cached action token for actionTokenAuth, refreshed when rejected by the API
+	actionMu        sync.Mutex
+	actionToken     string
+	actionTokenAuth string
+}
+
+// RequestObserver is notified after every API request, for example to collect metrics.
+// The status code is 0 when the request failed without a response.
+type RequestObserver interface {
+	ObserveRequest(name string, statusCode int, duration time.Duration)
 }
 
+// defaultEditTagBatchSize is the default maximum number of items sent in a single edit-tag request
+const defaultEditTagBatchSize = 250
+
 // Validate checks if the client is configured properly
 func (c *Client) Validate() error {
 	if c.baseURL == "" {
@@ -30,12 +55,23 @@ func (c *Client) Validate() error {
 		return fmt.Errorf("invalid base URL: %w", err)
 	}
 
-	if c.username == "" {
-		return fmt.Errorf("username is required")
+	// A pre-obtained auth token doesn't require logging in with the credentials
+	if c.authToken == "" {
+		if c.username == "" {
+			return fmt.Errorf("username is required")
+		}
+
+		if c.password == "" {
+			return fmt.Errorf("password or auth token is required")
+		}
+	}
+
+	if c.editTagBatchSize <= 0 {
+		return fmt.Errorf("edit-tag batch size must be greater than zero")
 	}
 
-	if c.password == "" {
-		return fmt.Errorf("password is required")
+	if err := c.retryPolicy.Validate(); err != nil {
+		return fmt.Errorf("invalid retry policy: %w", err)
 	}
 
 	return nil
@@ -60,6 +96,13 @@ func WithCredentials(username, password string) Option {
 	}
 }
 
+// WithAuthToken sets a pre-obtained auth token, used instead of logging in with the credentials
+func WithAuthToken(authToken string) Option {
+	return func(c *Client) {
+		c.authToken = authToken
+	}
+}
+
 // WithHTTPClient sets a custom HTTP client
 func WithHTTPClient(httpClient *http.Client) Option {
 	return func(c *Client) {
@@ -77,10 +120,51 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithEditTagBatchSize sets the maximum number of items sent in a single edit-tag request
+func WithEditTagBatchSize(size int) Option {
+	return func(c *Client) {
+		c.editTagBatchSize = size
+	}
+}
+
+// WithRequestObserver sets an observer notified after every API request
+func WithRequestObserver(observer RequestObserver) Option {
+	return func(c *Client) {
+		c.observer = observer
+	}
+}
+
+// WithRetryPolicy sets the policy used to retry the requests failing with transient errors
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithRateLimit limits the number of requests sent per second, to avoid overloading the server. A limit of zero disables it.
+func WithRateLimit(requestsPerSecond float64) Option {
+	return func(c *Client) {
+		if requestsPerSecond <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), 1)
+	}
+}
+
+// WithLogger sets the logger used to log every request and response at the debug level. Credentials and tokens are redacted.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
 // New creates a new FreshRSS client with the provided options
 func New(opts ...Option) (*Client, error) {
 	client := &Client{
-		httpClient: &http.Client{Timeout: 10 * time.Second},
+		httpClient:       &http.Client{Timeout: 10 * time.Second},
+		editTagBatchSize: defaultEditTagBatchSize,
+		retryPolicy:      DefaultRetryPolicy(),
 	}
 
 	for _, opt := range opts {
@@ -94,6 +178,70 @@ func New(opts ...Option) (*Client, error) {
 	return client, nil
 }
 
+// do executes the request identified by name, retrying it on transient errors as configured by the retry policy
+func (c *Client) do(name string, req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.doOnce(name, req)
+		if attempt >= c.retryPolicy.MaxAttempts || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		wait := c.retryPolicy.backoff(attempt)
+		if d, ok := retryAfter(resp, time.Now()); ok {
+			wait = min(d, c.retryPolicy.MaxBackoff)
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("error rewinding %s request body: %w", name, err)
+			}
+			req.Body = body
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// doOnce executes a single attempt of the request identified by name, logging it and notifying the request observer, if any.
+// When a rate limit is configured, it waits for its turn before sending the request.
+func (c *Client) doOnce(name string, req *http.Request) (*http.Response, error) {
+	if c.limiter != nil {
+		if err := c.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
+	c.logRequest(name, req)
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	duration := time.Since(start)
+
+	c.logResponse(name, req, resp, err, duration)
+
+	if c.observer != nil {
+		statusCode := 0
+		if resp != nil {
+			statusCode = resp.StatusCode
+		}
+		c.observer.ObserveRequest(name, statusCode, duration)
+	}
+
+	return resp, err
+}
+
 // setAuthHeaders adds authentication headers to an HTTP request
 func (c *Client) setAuthHeaders(req *http.Request, authToken string) {
 	if authToken != "" {
@@ -101,19 +249,26 @@ func (c *Client) setAuthHeaders(req *http.Request, authToken string) {
 	}
 }
 
-// GetAuthToken retrieves an authentication token from the FreshRSS API
+// GetAuthToken retrieves an authentication token from the FreshRSS API.
+// When a pre-obtained auth token is configured, it is returned without logging in.
 func (c *Client) GetAuthToken(ctx context.Context) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/accounts/ClientLogin", nil)
+	if c.authToken != "" {
+		return c.authToken, nil
+	}
+
+	// The credentials are sent in the request body, so they don't end up in the access logs of proxies
+	data := url.Values{}
+	data.Set("Email", c.username)
+	data.Set("Passwd", c.password)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/accounts/ClientLogin", bytes.NewBufferString(data.Encode()))
 	if err != nil {
 		return "", fmt.Errorf("error creating auth request: %w", err)
 	}
 
-	query := req.URL.Query()
-	query.Add("Email", c.username)
-	query.Add("Passwd", c.password)
-	req.URL.RawQuery = query.Encode()
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do("auth", req)
 	if err != nil {
 		return "", fmt.Errorf("error executing auth request: %w", err)
 	}
@@ -144,8 +299,8 @@ func (c *Client) GetAuthToken(ctx context.Context) (string, error) {
 	return "", fmt.Errorf("auth token not found in response")
 }
 
-// MarkAsRead marks items in a feed as read that are older than the specified days
-func (c *Client) MarkAsRead(ctx context.Context, authToken string, feedID string, olderThanDays int) error {
+// MarkAsRead marks items in a feed as read that are older than the specified cutoff time
+func (c *Client) MarkAsRead(ctx context.Context, authToken string, feedID string, olderThan time.Time) error {
 	if authToken == "" {
 		return fmt.Errorf("auth token is required")
 	}
@@ -154,37 +309,78 @@ func (c *Client) MarkAsRead(ctx context.Context, authToken string, feedID string
 		return fmt.Errorf("feed ID is required")
 	}
 
-	// Calculate cutoff time
-	cutoffTime := time.Now().AddDate(0, 0, -olderThanDays).UnixNano() / 1e3 // Microseconds
+	data := url.Values{}
+	data.Set("s", feedID)
+	data.Set("ts", fmt.Sprintf("%d", olderThan.UnixMicro()))
+
+	return c.postForm(ctx, authToken, "mark-as-read", "/reader/api/0/mark-all-as-read", data)
+}
+
+// EditTag adds and removes tags (states or labels) to the specified items. For example, adding StateRead marks the items as read,
+// while removing it marks them as unread. Items are sent in batches, to avoid hitting request size limits on the server.
+func (c *Client) EditTag(ctx context.Context, authToken string, itemIDs []string, add []string, remove []string) error {
+	if authToken == "" {
+		return fmt.Errorf("auth token is required")
+	}
+
+	if len(add) == 0 && len(remove) == 0 {
+		return fmt.Errorf("at least one tag to add or remove is required")
+	}
+
+	for start := 0; start < len(itemIDs); start += c.editTagBatchSize {
+		end := min(start+c.editTagBatchSize, len(itemIDs))
+		if err := c.editTagBatch(ctx, authToken, itemIDs[start:end], add, remove); err != nil {
+			return err
+		}
+	}
 
-	// Prepare request
-	endpoint := fmt.Sprintf("%s/reader/api/0/mark-all-as-read", c.baseURL)
+	return nil
+}
 
-	// Prepare form data
+// editTagBatch executes a single edit-tag request for the specified items
+func (c *Client) editTagBatch(ctx context.Context, authToken string, itemIDs []string, add []string, remove []string) error {
 	data := url.Values{}
-	data.Set("s", feedID)
-	data.Set("ts", fmt.Sprintf("%d", cutoffTime))
+	for _, tag := range add {
+		data.Add("a", tag)
+	}
+	for _, tag := range remove {
+		data.Add("r", tag)
+	}
+	for _, id := range itemIDs {
+		data.Add("i", id)
+	}
+
+	return c.postForm(ctx, authToken, "edit-tag", "/reader/api/0/edit-tag", data)
+}
 
-	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBufferString(data.Encode()))
+// getJSON executes an authenticated GET request against the specified API path and decodes the JSON response into out.
+// The name is used to identify the request in error messages.
+func (c *Client) getJSON(ctx context.Context, authToken string, name string, path string, query url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
 	if err != nil {
-		return fmt.Errorf("error creating mark-as-read request: %w", err)
+		return fmt.Errorf("error creating %s request: %w", name, err)
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("output", "json")
+	req.URL.RawQuery = query.Encode()
 
 	c.setAuthHeaders(req, authToken)
 
-	// Execute request
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(name, req)
 	if err != nil {
-		return fmt.Errorf("error executing mark-as-read request: %w", err)
+		return fmt.Errorf("error executing %s request: %w", name, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("mark-as-read request failed with unexpected status code %d: %s", resp.StatusCode, string(bodyBytes))
+		return newStatusError(name, resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding %s response: %w", name, err)
 	}
 
 	return nil
@@ -2,6 +2,7 @@ package client_test
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"os"
 	"testing"
@@ -14,18 +15,29 @@ import (
 	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
 )
 
+// initTestClient creates a client without retries, so each mocked response is only requested once
 func initTestClient(t *testing.T) *client.Client {
 	t.Helper()
 	c, err := client.New(
 		client.WithBaseURL("https://freshrss.example.com"),
 		client.WithCredentials("test", "pass"),
 		client.WithTimeout(1*time.Second),
+		client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 1}),
 	)
 
 	require.NoError(t, err)
 	return c
 }
 
+// mockActionToken mocks the request of the action token required by the write requests
+func mockActionToken() {
+	gock.New("https://freshrss.example.com").
+		Get("/reader/api/0/token").
+		MatchHeader("Authorization", "GoogleLogin auth=test/auth-token").
+		Reply(200).
+		BodyString("test-action-token\n")
+}
+
 func TestNew(t *testing.T) {
 	t.Parallel()
 
@@ -53,6 +65,42 @@ func TestNew(t *testing.T) {
 		assert.Nil(t, c)
 	})
 
+	t.Run("WithInvalidEditTagBatchSize", func(t *testing.T) {
+		t.Parallel()
+		c, err := client.New(
+			client.WithBaseURL("https://example.com"),
+			client.WithCredentials("user", "pass"),
+			client.WithEditTagBatchSize(0),
+		)
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "edit-tag batch size must be greater than zero")
+		assert.Nil(t, c)
+	})
+
+	t.Run("WithAuthTokenAndNoCredentials", func(t *testing.T) {
+		t.Parallel()
+		c, err := client.New(
+			client.WithBaseURL("https://example.com"),
+			client.WithAuthToken("user/token"),
+		)
+
+		assert.Nil(t, err)
+		assert.NotNil(t, c)
+	})
+
+	t.Run("WithMissingPassword", func(t *testing.T) {
+		t.Parallel()
+		c, err := client.New(
+			client.WithBaseURL("https://example.com"),
+			client.WithCredentials("user", ""),
+		)
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "password or auth token is required")
+		assert.Nil(t, c)
+	})
+
 	t.Run("WithMissingUsername", func(t *testing.T) {
 		t.Parallel()
 		c, err := client.New(
@@ -74,9 +122,9 @@ func TestGetAuthToken(t *testing.T) {
 
 		// Mock the request
 		gock.New("https://freshrss.example.com").
-			Get("/accounts/ClientLogin").
-			MatchParam("Email", "test").
-			MatchParam("Passwd", "pass").
+			Post("/accounts/ClientLogin").
+			MatchType("url").
+			BodyString("Email=test&Passwd=pass").
 			Reply(200).
 			BodyString(string(response))
 
@@ -88,6 +136,21 @@ func TestGetAuthToken(t *testing.T) {
 		assert.True(t, gock.IsDone())
 	})
 
+	t.Run("WithPreObtainedAuthToken", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		c, err := client.New(
+			client.WithBaseURL("https://freshrss.example.com"),
+			client.WithAuthToken("user/pre-obtained-token"),
+		)
+		require.NoError(t, err)
+
+		token, err := c.GetAuthToken(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "user/pre-obtained-token", token)
+		assert.False(t, gock.HasUnmatchedRequest())
+	})
+
 	t.Run("WithUnauthorizedResponse", func(t *testing.T) {
 		defer gock.Off() // Flush pending mocks after test execution
 
@@ -96,9 +159,9 @@ func TestGetAuthToken(t *testing.T) {
 
 		// Mock the request
 		gock.New("https://freshrss.example.com").
-			Get("/accounts/ClientLogin").
-			MatchParam("Email", "test").
-			MatchParam("Passwd", "pass").
+			Post("/accounts/ClientLogin").
+			MatchType("url").
+			BodyString("Email=test&Passwd=pass").
 			Reply(401).
 			BodyString(string(response))
 
@@ -119,9 +182,9 @@ func TestGetAuthToken(t *testing.T) {
 
 		// Mock the request
 		gock.New("https://freshrss.example.com").
-			Get("/accounts/ClientLogin").
-			MatchParam("Email", "test").
-			MatchParam("Passwd", "pass").
+			Post("/accounts/ClientLogin").
+			MatchType("url").
+			BodyString("Email=test&Passwd=pass").
 			Reply(200).
 			BodyString(string(response))
 
@@ -139,14 +202,14 @@ func TestMarkAsRead(t *testing.T) {
 	t.Run("WithEmptyFeedID_ReturnsError", func(t *testing.T) {
 		c := initTestClient(t)
 
-		err := c.MarkAsRead(context.Background(), "test/auth-token", "", 7)
+		err := c.MarkAsRead(context.Background(), "test/auth-token", "", time.Now())
 		require.Error(t, err)
 		assert.Equal(t, err.Error(), "feed ID is required")
 	})
 
 	t.Run("WithEmptyAuthToken_ReturnsError", func(t *testing.T) {
 		c := initTestClient(t)
-		err := c.MarkAsRead(context.Background(), "", "feed-id", 7)
+		err := c.MarkAsRead(context.Background(), "", "feed-id", time.Now())
 		require.Error(t, err)
 		assert.Equal(t, err.Error(), "auth token is required")
 	})
@@ -154,18 +217,22 @@ func TestMarkAsRead(t *testing.T) {
 	t.Run("WithUnexpectedResponse", func(t *testing.T) {
 		defer gock.Off() // Flush pending mocks after test execution
 
-		// Mock the request
+		// The action token is refreshed once before giving up
+		mockActionToken()
+		mockActionToken()
 		gock.New("https://freshrss.example.com").
 			Post("/reader/api/0/mark-all-as-read").
 			MatchHeader("Authorization", "GoogleLogin auth=test/auth-token").
 			MatchHeader("Content-Type", "application/x-www-form-urlencoded").
+			Times(2).
 			Reply(401)
 
 		c := initTestClient(t)
 
-		err := c.MarkAsRead(context.Background(), "test/auth-token", "feed-id", 7)
+		err := c.MarkAsRead(context.Background(), "test/auth-token", "feed-id", time.Now())
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "mark-as-read request failed with unexpected status code 401")
+		assert.ErrorIs(t, err, client.ErrUnauthorized)
 		assert.True(t, gock.IsDone())
 	})
 
@@ -175,18 +242,165 @@ func TestMarkAsRead(t *testing.T) {
 		response, err := os.ReadFile("testdata/mark_as_read_200.txt")
 		require.NoError(t, err)
 
+		cutoff := time.Unix(1743400000, 0)
+
 		// Mock the request
+		mockActionToken()
 		gock.New("https://freshrss.example.com").
 			Post("/reader/api/0/mark-all-as-read").
 			MatchHeader("Authorization", "GoogleLogin auth=test/auth-token").
 			MatchHeader("Content-Type", "application/x-www-form-urlencoded").
+			BodyString("T=test-action-token&s=feed-id&ts=1743400000000000").
 			Reply(200).
 			BodyString(string(response))
 
 		c := initTestClient(t)
 
-		err = c.MarkAsRead(context.Background(), "test/auth-token", "feed-id", 7)
+		err = c.MarkAsRead(context.Background(), "test/auth-token", "feed-id", cutoff)
+		require.NoError(t, err)
+		assert.True(t, gock.IsDone())
+	})
+}
+
+func TestEditTag(t *testing.T) {
+	t.Run("WithEmptyAuthToken_ReturnsError", func(t *testing.T) {
+		c := initTestClient(t)
+
+		err := c.EditTag(context.Background(), "", []string{"1"}, []string{client.StateRead}, nil)
+		require.Error(t, err)
+		assert.Equal(t, err.Error(), "auth token is required")
+	})
+
+	t.Run("WithoutTags_ReturnsError", func(t *testing.T) {
+		c := initTestClient(t)
+
+		err := c.EditTag(context.Background(), "test/auth-token", []string{"1"}, nil, nil)
+		require.Error(t, err)
+		assert.Equal(t, err.Error(), "at least one tag to add or remove is required")
+	})
+
+	t.Run("WithNoItems_DoesNothing", func(t *testing.T) {
+		c := initTestClient(t)
+
+		err := c.EditTag(context.Background(), "test/auth-token", nil, []string{client.StateRead}, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("WithUnexpectedResponse", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		mockActionToken()
+		gock.New("https://freshrss.example.com").
+			Post("/reader/api/0/edit-tag").
+			Reply(500)
+
+		c := initTestClient(t)
+
+		err := c.EditTag(context.Background(), "test/auth-token", []string{"1"}, []string{client.StateRead}, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "edit-tag request failed with unexpected status code 500")
+		assert.NotErrorIs(t, err, client.ErrUnauthorized)
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("WithValidResponse", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		mockActionToken()
+		gock.New("https://freshrss.example.com").
+			Post("/reader/api/0/edit-tag").
+			MatchHeader("Authorization", "GoogleLogin auth=test/auth-token").
+			MatchHeader("Content-Type", "application/x-www-form-urlencoded").
+			BodyString("T=test-action-token&a=user%2F-%2Flabel%2FLater&i=1&i=2&r=user%2F-%2Fstate%2Fcom.google%2Fread").
+			Reply(200).
+			BodyString("OK")
+
+		c := initTestClient(t)
+
+		err := c.EditTag(context.Background(), "test/auth-token", []string{"1", "2"}, []string{"user/-/label/Later"}, []string{client.StateRead})
 		require.NoError(t, err)
 		assert.True(t, gock.IsDone())
 	})
+
+	t.Run("WithMoreItemsThanBatchSize_SendsMultipleRequests", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		// The action token is only requested once, and reused by the following requests
+		mockActionToken()
+		gock.New("https://freshrss.example.com").
+			Post("/reader/api/0/edit-tag").
+			BodyString("T=test-action-token&a=user%2F-%2Fstate%2Fcom.google%2Fread&i=1&i=2").
+			Reply(200)
+		gock.New("https://freshrss.example.com").
+			Post("/reader/api/0/edit-tag").
+			BodyString("T=test-action-token&a=user%2F-%2Fstate%2Fcom.google%2Fread&i=3").
+			Reply(200)
+
+		c, err := client.New(
+			client.WithBaseURL("https://freshrss.example.com"),
+			client.WithCredentials("test", "pass"),
+			client.WithEditTagBatchSize(2),
+		)
+		require.NoError(t, err)
+
+		err = c.EditTag(context.Background(), "test/auth-token", []string{"1", "2", "3"}, []string{client.StateRead}, nil)
+		require.NoError(t, err)
+		assert.True(t, gock.IsDone())
+	})
+}
+
+// requestObserverFunc implements the client.RequestObserver interface with a function
+type requestObserverFunc func(name string, statusCode int, duration time.Duration)
+
+func (f requestObserverFunc) ObserveRequest(name string, statusCode int, duration time.Duration) {
+	f(name, statusCode, duration)
+}
+
+func TestRequestObserver(t *testing.T) {
+	defer gock.Off() // Flush pending mocks after test execution
+
+	gock.New("https://freshrss.example.com").
+		Get("/reader/api/0/token").
+		Reply(401)
+
+	var observed []string
+	c, err := client.New(
+		client.WithBaseURL("https://freshrss.example.com"),
+		client.WithCredentials("test", "pass"),
+		client.WithRequestObserver(requestObserverFunc(func(name string, statusCode int, duration time.Duration) {
+			observed = append(observed, fmt.Sprintf("%s %d", name, statusCode))
+		})),
+	)
+	require.NoError(t, err)
+
+	err = c.MarkAsRead(context.Background(), "test/auth-token", "feed-id", time.Now())
+	require.Error(t, err)
+	assert.Equal(t, []string{"token 401"}, observed)
+}
+
+func TestRateLimit(t *testing.T) {
+	defer gock.Off() // Flush pending mocks after test execution
+
+	gock.New("https://freshrss.example.com").
+		Get("/reader/api/0/tag/list").
+		Times(3).
+		Reply(200).
+		JSON(map[string]any{"tags": []any{}})
+
+	c, err := client.New(
+		client.WithBaseURL("https://freshrss.example.com"),
+		client.WithCredentials("test", "pass"),
+		client.WithRateLimit(20),
+	)
+	require.NoError(t, err)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := c.TagList(context.Background(), "test/auth-token")
+		require.NoError(t, err)
+	}
+
+	// The first request is sent immediately, and the following ones wait 50ms each
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	assert.True(t, gock.IsDone())
 }
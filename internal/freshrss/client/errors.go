@@ -0,0 +1,38 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrUnauthorized is matched by the errors of requests rejected by the API because of an invalid or expired auth token
+var ErrUnauthorized = errors.New("unauthorized")
+
+// StatusError is returned when the API responds with an unexpected HTTP status code
+type StatusError struct {
+	Request    string
+	StatusCode int
+	Body       string
+}
+
+// newStatusError creates a StatusError from the response of the named request
+func newStatusError(name string, resp *http.Response) *StatusError {
+	bodyBytes, _ := io.ReadAll(resp.Body)
+
+	return &StatusError{
+		Request:    name,
+		StatusCode: resp.StatusCode,
+		Body:       string(bodyBytes),
+	}
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s request failed with unexpected status code %d: %s", e.Request, e.StatusCode, e.Body)
+}
+
+// Is allows matching errors caused by an unauthorized response with errors.Is(err, ErrUnauthorized)
+func (e *StatusError) Is(target error) bool {
+	return target == ErrUnauthorized && e.StatusCode == http.StatusUnauthorized
+}
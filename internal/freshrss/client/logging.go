@@ -0,0 +1,121 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// redacted replaces the credentials in the logs
+const redacted = "[REDACTED]"
+
+// maxLoggedBody is the maximum number of bytes of the response bodies written to the logs
+const maxLoggedBody = 1024
+
+// sensitiveFields are the form fields and query parameters holding credentials: the password and the action token
+var sensitiveFields = map[string]bool{"Passwd": true, "T": true}
+
+// loginTokenPattern matches the tokens returned by the login endpoint (ex: "Auth=user/token")
+var loginTokenPattern = regexp.MustCompile(`(?m)^(SID|LSID|Auth)=.*$`)
+
+// debugEnabled checks if the requests must be logged, which is only the case at the debug level
+func (c *Client) debugEnabled(req *http.Request) bool {
+	return c.logger != nil && c.logger.Enabled(req.Context(), slog.LevelDebug)
+}
+
+// logRequest logs the request identified by name at the debug level, without its credentials
+func (c *Client) logRequest(name string, req *http.Request) {
+	if !c.debugEnabled(req) {
+		return
+	}
+
+	u := *req.URL
+	u.RawQuery = redactFields(u.RawQuery)
+	attrs := []any{"request", name, "method", req.Method, "url", u.String()}
+
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		attrs = append(attrs, "authorization", redactAuthorization(auth))
+	}
+
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			attrs = append(attrs, "body", redactFields(string(data)))
+		}
+	}
+
+	c.logger.DebugContext(req.Context(), "API request", attrs...)
+}
+
+// logResponse logs the response of the request identified by name at the debug level, without the tokens it may contain.
+// The response body is read to be logged, and replaced by a copy so it can still be read by the caller.
+func (c *Client) logResponse(name string, req *http.Request, resp *http.Response, err error, duration time.Duration) {
+	if !c.debugEnabled(req) {
+		return
+	}
+
+	if err != nil {
+		c.logger.DebugContext(req.Context(), "API request failed", "request", name, "duration", duration, "error", err)
+		return
+	}
+
+	data, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if readErr != nil {
+		c.logger.DebugContext(req.Context(), "API response", "request", name, "status", resp.StatusCode, "duration", duration, "error", readErr)
+		return
+	}
+
+	c.logger.DebugContext(req.Context(), "API response",
+		"request", name,
+		"status", resp.StatusCode,
+		"duration", duration,
+		"body", redactResponse(name, data),
+	)
+}
+
+// redactFields replaces the values of the sensitive fields of a form body or query string, keeping the other fields as they are
+func redactFields(encoded string) string {
+	if encoded == "" {
+		return ""
+	}
+
+	pairs := strings.Split(encoded, "&")
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		if sensitiveFields[key] {
+			pairs[i] = key + "=" + redacted
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// redactAuthorization replaces the auth token of the Authorization header, keeping its scheme
+func redactAuthorization(auth string) string {
+	if prefix, _, ok := strings.Cut(auth, "="); ok {
+		return prefix + "=" + redacted
+	}
+
+	return redacted
+}
+
+// redactResponse replaces the tokens returned by the login and token requests, and truncates long bodies
+func redactResponse(name string, data []byte) string {
+	if name == "token" {
+		return redacted
+	}
+
+	body := loginTokenPattern.ReplaceAllString(string(data), "$1="+redacted)
+	if len(body) > maxLoggedBody {
+		body = body[:maxLoggedBody] + "...(truncated)"
+	}
+
+	return body
+}
@@ -0,0 +1,79 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+)
+
+func TestLogger(t *testing.T) {
+	t.Run("Logs the requests without credentials", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		gock.New("https://freshrss.example.com").
+			Post("/accounts/ClientLogin").
+			Reply(200).
+			BodyString("SID=test/auth-token\nLSID=null\nAuth=test/auth-token\n")
+		mockActionToken()
+		gock.New("https://freshrss.example.com").
+			Post("/reader/api/0/mark-all-as-read").
+			Reply(200).
+			BodyString("OK")
+
+		var b bytes.Buffer
+		c, err := client.New(
+			client.WithBaseURL("https://freshrss.example.com"),
+			client.WithCredentials("test", "s3cret"),
+			client.WithLogger(slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		)
+		require.NoError(t, err)
+
+		ctx := context.Background()
+		authToken, err := c.GetAuthToken(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "test/auth-token", authToken, "the response body must still be readable after being logged")
+
+		err = c.MarkAsRead(ctx, authToken, "feed/1", time.Now())
+		require.NoError(t, err)
+		assert.True(t, gock.IsDone())
+
+		logs := b.String()
+		assert.Contains(t, logs, `msg="API request" request=auth method=POST url=https://freshrss.example.com/accounts/ClientLogin body="Email=test&Passwd=[REDACTED]"`)
+		assert.Contains(t, logs, `msg="API response" request=auth status=200`)
+		assert.Contains(t, logs, `authorization="GoogleLogin auth=[REDACTED]"`)
+		assert.Contains(t, logs, `msg="API request" request=mark-as-read method=POST`)
+		assert.Contains(t, logs, `body="T=[REDACTED]&s=feed%2F1&ts=`)
+		assert.NotContains(t, logs, "s3cret")
+		assert.NotContains(t, logs, "test/auth-token")
+		assert.NotContains(t, logs, "test-action-token")
+	})
+
+	t.Run("Doesn't log the requests above the debug level", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		gock.New("https://freshrss.example.com").
+			Get("/reader/api/0/tag/list").
+			Reply(200).
+			JSON(map[string]any{"tags": []any{}})
+
+		var b bytes.Buffer
+		c, err := client.New(
+			client.WithBaseURL("https://freshrss.example.com"),
+			client.WithCredentials("test", "s3cret"),
+			client.WithLogger(slog.New(slog.NewTextHandler(&b, nil))),
+		)
+		require.NoError(t, err)
+
+		_, err = c.TagList(context.Background(), "test/auth-token")
+		require.NoError(t, err)
+		assert.Empty(t, b.String())
+	})
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy defines how requests failing with transient errors are retried.
+// Network errors and responses with the 429 or 5xx status codes are retried, waiting an exponential backoff between attempts.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of a request, including the first one. 1 disables the retries.
+	MaxAttempts int
+	// InitialBackoff is the time waited before the first retry. It doubles on each subsequent retry.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum time waited between attempts, including the time requested by the Retry-After header.
+	MaxBackoff time.Duration
+	// Jitter is the fraction of the backoff randomly added or removed, to spread the retries of concurrent requests (ex: 0.2 for ±20%).
+	Jitter float64
+}
+
+// DefaultRetryPolicy returns the retry policy used by the client when none is configured
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		Jitter:         0.2,
+	}
+}
+
+// Validate checks if the retry policy is properly configured
+func (p RetryPolicy) Validate() error {
+	if p.MaxAttempts < 1 {
+		return fmt.Errorf("max attempts must be at least 1")
+	}
+
+	if p.InitialBackoff < 0 || p.MaxBackoff < 0 {
+		return fmt.Errorf("backoff can't be negative")
+	}
+
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1")
+	}
+
+	return nil
+}
+
+// backoff returns the time to wait before the specified retry, starting at 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < retry && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	backoff = min(backoff, p.MaxBackoff)
+	if p.Jitter > 0 {
+		backoff += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(backoff))
+	}
+
+	return backoff
+}
+
+// shouldRetry checks if a request failed with a transient error. Requests cancelled by their context are never retried.
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	if req.Body != nil && req.GetBody == nil {
+		// The body was consumed and can't be sent again
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// retryAfter parses the Retry-After header of the response, either in seconds or as an HTTP date
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,113 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+)
+
+func initRetryTestClient(t *testing.T, maxAttempts int) *client.Client {
+	t.Helper()
+	c, err := client.New(
+		client.WithBaseURL("https://freshrss.example.com"),
+		client.WithCredentials("test", "pass"),
+		client.WithRetryPolicy(client.RetryPolicy{
+			MaxAttempts:    maxAttempts,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     10 * time.Millisecond,
+			Jitter:         0.5,
+		}),
+	)
+
+	require.NoError(t, err)
+	return c
+}
+
+func TestRetryPolicy_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, client.DefaultRetryPolicy().Validate())
+	assert.ErrorContains(t, client.RetryPolicy{}.Validate(), "max attempts must be at least 1")
+	assert.ErrorContains(t, client.RetryPolicy{MaxAttempts: 1, InitialBackoff: -time.Second}.Validate(), "backoff can't be negative")
+	assert.ErrorContains(t, client.RetryPolicy{MaxAttempts: 1, Jitter: 1.5}.Validate(), "jitter must be between 0 and 1")
+}
+
+func TestRetry(t *testing.T) {
+	t.Run("RetriesTransientErrors", func(t *testing.T) {
+		defer gock.Off()
+
+		mockActionToken()
+		gock.New("https://freshrss.example.com").
+			Post("/reader/api/0/mark-all-as-read").
+			Reply(502)
+		gock.New("https://freshrss.example.com").
+			Post("/reader/api/0/mark-all-as-read").
+			Reply(429).
+			SetHeader("Retry-After", "1")
+		gock.New("https://freshrss.example.com").
+			Post("/reader/api/0/mark-all-as-read").
+			BodyString("T=test-action-token&s=feed-id&ts=.*").
+			Reply(200)
+
+		c := initRetryTestClient(t, 3)
+		err := c.MarkAsRead(context.Background(), "test/auth-token", "feed-id", time.Now())
+
+		assert.NoError(t, err)
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("RetriesNetworkErrors", func(t *testing.T) {
+		defer gock.Off()
+
+		gock.New("https://freshrss.example.com").
+			Get("/reader/api/0/tag/list").
+			ReplyError(assert.AnError)
+		gock.New("https://freshrss.example.com").
+			Get("/reader/api/0/tag/list").
+			Reply(200).
+			JSON(map[string]any{"tags": []any{}})
+
+		c := initRetryTestClient(t, 2)
+		_, err := c.TagList(context.Background(), "test/auth-token")
+
+		assert.NoError(t, err)
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("StopsAfterMaxAttempts", func(t *testing.T) {
+		defer gock.Off()
+
+		mockActionToken()
+		gock.New("https://freshrss.example.com").
+			Post("/reader/api/0/edit-tag").
+			Times(2).
+			Reply(503)
+
+		c := initRetryTestClient(t, 2)
+		err := c.EditTag(context.Background(), "test/auth-token", []string{"1"}, []string{client.StateRead}, nil)
+
+		assert.ErrorContains(t, err, "edit-tag request failed with unexpected status code 503")
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("DoesNotRetryClientErrors", func(t *testing.T) {
+		defer gock.Off()
+
+		mockActionToken()
+		gock.New("https://freshrss.example.com").
+			Post("/reader/api/0/mark-all-as-read").
+			Reply(400)
+
+		c := initRetryTestClient(t, 3)
+		err := c.MarkAsRead(context.Background(), "test/auth-token", "feed-id", time.Now())
+
+		assert.ErrorContains(t, err, "unexpected status code 400")
+		assert.True(t, gock.IsDone())
+	})
+}
@@ -0,0 +1,201 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Google Reader state stream IDs
+const (
+	StateRead        = "user/-/state/com.google/read"
+	StateStarred     = "user/-/state/com.google/starred"
+	StateReadingList = "user/-/state/com.google/reading-list"
+)
+
+// StreamOptions defines the query parameters used when fetching the contents of a stream
+type StreamOptions struct {
+	// Count is the maximum number of items to return (n)
+	Count int
+	// NewerThan only returns items newer than the specified time (ot)
+	NewerThan time.Time
+	// OlderThan only returns items older than the specified time (nt)
+	OlderThan time.Time
+	// ExcludeTarget excludes items having the specified stream ID, like StateRead (xt)
+	ExcludeTarget string
+	// IncludeTarget only returns items having the specified stream ID (it)
+	IncludeTarget string
+	// Continuation is the continuation token returned by a previous call (c)
+	Continuation string
+}
+
+// Origin represents the feed an item was published in
+type Origin struct {
+	StreamID string
+	Title    string
+	HTMLURL  string
+}
+
+// Item represents a single article returned by the FreshRSS API
+type Item struct {
+	ID         string
+	Title      string
+	Author     string
+	Published  time.Time
+	Crawled    time.Time
+	URL        string
+	Content    string
+	Categories []string
+	Origin     Origin
+}
+
+// HasCategory checks if the item is tagged with the specified stream ID (ex: StateStarred)
+func (i Item) HasCategory(streamID string) bool {
+	for _, category := range i.Categories {
+		if category == streamID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StreamContents represents a page of items of a stream
+type StreamContents struct {
+	Items        []Item
+	Continuation string
+}
+
+// streamItem maps a single item of the stream contents JSON response
+type streamItem struct {
+	ID            string   `json:"id"`
+	Title         string   `json:"title"`
+	Author        string   `json:"author"`
+	Published     int64    `json:"published"`
+	CrawlTimeMsec string   `json:"crawlTimeMsec"`
+	Categories    []string `json:"categories"`
+	Alternate     []struct {
+		Href string `json:"href"`
+	} `json:"alternate"`
+	Canonical []struct {
+		Href string `json:"href"`
+	} `json:"canonical"`
+	Summary struct {
+		Content string `json:"content"`
+	} `json:"summary"`
+	Content struct {
+		Content string `json:"content"`
+	} `json:"content"`
+	Origin struct {
+		StreamID string `json:"streamId"`
+		Title    string `json:"title"`
+		HTMLURL  string `json:"htmlUrl"`
+	} `json:"origin"`
+}
+
+// toItem converts the JSON representation of an item into an Item
+func (i streamItem) toItem() Item {
+	item := Item{
+		ID:         i.ID,
+		Title:      i.Title,
+		Author:     i.Author,
+		Published:  time.Unix(i.Published, 0),
+		Categories: i.Categories,
+		Origin: Origin{
+			StreamID: i.Origin.StreamID,
+			Title:    i.Origin.Title,
+			HTMLURL:  i.Origin.HTMLURL,
+		},
+	}
+
+	switch {
+	case len(i.Alternate) > 0:
+		item.URL = i.Alternate[0].Href
+	case len(i.Canonical) > 0:
+		item.URL = i.Canonical[0].Href
+	}
+
+	item.Content = i.Summary.Content
+	if i.Content.Content != "" {
+		item.Content = i.Content.Content
+	}
+
+	if msec, err := strconv.ParseInt(i.CrawlTimeMsec, 10, 64); err == nil {
+		item.Crawled = time.UnixMilli(msec)
+	}
+
+	return item
+}
+
+// streamContentsResponse maps the JSON response of the stream contents endpoint
+type streamContentsResponse struct {
+	Items        []streamItem `json:"items"`
+	Continuation string       `json:"continuation"`
+}
+
+// StreamContents fetches a page of items from the specified stream (feed, label or state)
+func (c *Client) StreamContents(ctx context.Context, authToken string, streamID string, opts StreamOptions) (*StreamContents, error) {
+	if authToken == "" {
+		return nil, fmt.Errorf("auth token is required")
+	}
+
+	if streamID == "" {
+		return nil, fmt.Errorf("stream ID is required")
+	}
+
+	query := opts.query()
+
+	var payload streamContentsResponse
+	if err := c.getJSON(ctx, authToken, "stream contents", "/reader/api/0/stream/contents/"+escapeStreamID(streamID), query, &payload); err != nil {
+		return nil, err
+	}
+
+	contents := &StreamContents{
+		Items:        make([]Item, 0, len(payload.Items)),
+		Continuation: payload.Continuation,
+	}
+
+	for _, item := range payload.Items {
+		contents.Items = append(contents.Items, item.toItem())
+	}
+
+	return contents, nil
+}
+
+// query returns the query parameters of the stream contents endpoint matching the options
+func (opts StreamOptions) query() url.Values {
+	query := url.Values{}
+	if opts.Count > 0 {
+		query.Set("n", fmt.Sprintf("%d", opts.Count))
+	}
+	if !opts.NewerThan.IsZero() {
+		query.Set("ot", fmt.Sprintf("%d", opts.NewerThan.Unix()))
+	}
+	if !opts.OlderThan.IsZero() {
+		query.Set("nt", fmt.Sprintf("%d", opts.OlderThan.Unix()))
+	}
+	if opts.ExcludeTarget != "" {
+		query.Set("xt", opts.ExcludeTarget)
+	}
+	if opts.IncludeTarget != "" {
+		query.Set("it", opts.IncludeTarget)
+	}
+	if opts.Continuation != "" {
+		query.Set("c", opts.Continuation)
+	}
+
+	return query
+}
+
+// escapeStreamID escapes each segment of a stream ID so it can be used as part of the URL path
+func escapeStreamID(streamID string) string {
+	segments := strings.Split(streamID, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,117 @@
+package client_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+)
+
+func TestStreamContents(t *testing.T) {
+	t.Run("WithEmptyStreamID_ReturnsError", func(t *testing.T) {
+		c := initTestClient(t)
+
+		_, err := c.StreamContents(context.Background(), "test/auth-token", "", client.StreamOptions{})
+		require.Error(t, err)
+		assert.Equal(t, err.Error(), "stream ID is required")
+	})
+
+	t.Run("WithEmptyAuthToken_ReturnsError", func(t *testing.T) {
+		c := initTestClient(t)
+
+		_, err := c.StreamContents(context.Background(), "", "feed/22", client.StreamOptions{})
+		require.Error(t, err)
+		assert.Equal(t, err.Error(), "auth token is required")
+	})
+
+	t.Run("WithUnexpectedResponse", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		gock.New("https://freshrss.example.com").
+			Get("/reader/api/0/stream/contents/feed/22").
+			MatchHeader("Authorization", "GoogleLogin auth=test/auth-token").
+			Reply(401)
+
+		c := initTestClient(t)
+
+		_, err := c.StreamContents(context.Background(), "test/auth-token", "feed/22", client.StreamOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "stream contents request failed with unexpected status code 401")
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("WithValidResponse", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		response, err := os.ReadFile("testdata/stream_contents_200.json")
+		require.NoError(t, err)
+
+		newerThan := time.Unix(1743000000, 0)
+		olderThan := time.Unix(1743500000, 0)
+
+		gock.New("https://freshrss.example.com").
+			Get("/reader/api/0/stream/contents/feed/22").
+			MatchHeader("Authorization", "GoogleLogin auth=test/auth-token").
+			MatchParam("output", "json").
+			MatchParam("n", "100").
+			MatchParam("ot", "1743000000").
+			MatchParam("nt", "1743500000").
+			MatchParam("xt", client.StateRead).
+			MatchParam("it", "user/-/label/News").
+			MatchParam("c", "abc").
+			Reply(200).
+			BodyString(string(response))
+
+		c := initTestClient(t)
+
+		contents, err := c.StreamContents(context.Background(), "test/auth-token", "feed/22", client.StreamOptions{
+			Count:         100,
+			NewerThan:     newerThan,
+			OlderThan:     olderThan,
+			ExcludeTarget: client.StateRead,
+			IncludeTarget: "user/-/label/News",
+			Continuation:  "abc",
+		})
+		require.NoError(t, err)
+		require.Len(t, contents.Items, 2)
+		assert.Equal(t, "tag:google.com,2005:reader/item/00062f1d3a7b2c10", contents.Items[0].ID)
+		assert.Equal(t, "First article", contents.Items[0].Title)
+		assert.Equal(t, "Jane Doe", contents.Items[0].Author)
+		assert.Equal(t, "https://news.example.com/first", contents.Items[0].URL)
+		assert.Equal(t, "<p>First article content</p>", contents.Items[0].Content)
+		assert.Equal(t, time.Unix(1743400000, 0), contents.Items[0].Published)
+		assert.Equal(t, time.UnixMilli(1743400000000), contents.Items[0].Crawled)
+		assert.Equal(t, []string{client.StateReadingList, "user/-/label/News"}, contents.Items[0].Categories)
+		assert.Equal(t, client.Origin{
+			StreamID: "feed/22",
+			Title:    "Example News",
+			HTMLURL:  "https://news.example.com",
+		}, contents.Items[0].Origin)
+		assert.False(t, contents.Items[0].HasCategory(client.StateStarred))
+		assert.True(t, contents.Items[1].HasCategory(client.StateStarred))
+		assert.Equal(t, "1743300000000000", contents.Continuation)
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("WithLabelStreamID", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		gock.New("https://freshrss.example.com").
+			Get("/reader/api/0/stream/contents/user/-/label/Dev Ops").
+			Reply(200).
+			BodyString(`{"items":[]}`)
+
+		c := initTestClient(t)
+
+		contents, err := c.StreamContents(context.Background(), "test/auth-token", "user/-/label/Dev Ops", client.StreamOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, contents.Items)
+		assert.True(t, gock.IsDone())
+	})
+}
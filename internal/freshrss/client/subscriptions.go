@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// LabelPrefix is the prefix of the stream IDs of user labels (categories and tags)
+const LabelPrefix = "user/-/label/"
+
+// Category represents a category (folder) a subscription belongs to
+type Category struct {
+	ID    string
+	Label string
+}
+
+// Subscription represents a feed the user is subscribed to
+type Subscription struct {
+	ID         string
+	Title      string
+	URL        string
+	HTMLURL    string
+	Categories []Category
+}
+
+// Tag represents a label or state stream returned by the tag list endpoint
+type Tag struct {
+	ID   string
+	Type string
+}
+
+// Label returns the human readable name of the tag (ex: "DevOps" for "user/-/label/DevOps")
+func (t Tag) Label() string {
+	if strings.HasPrefix(t.ID, LabelPrefix) {
+		return strings.TrimPrefix(t.ID, LabelPrefix)
+	}
+
+	return t.ID
+}
+
+// subscriptionListResponse maps the JSON response of the subscription list endpoint
+type subscriptionListResponse struct {
+	Subscriptions []struct {
+		ID         string `json:"id"`
+		Title      string `json:"title"`
+		URL        string `json:"url"`
+		HTMLURL    string `json:"htmlUrl"`
+		Categories []struct {
+			ID    string `json:"id"`
+			Label string `json:"label"`
+		} `json:"categories"`
+	} `json:"subscriptions"`
+}
+
+// tagListResponse maps the JSON response of the tag list endpoint
+type tagListResponse struct {
+	Tags []struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	} `json:"tags"`
+}
+
+// unreadCountResponse maps the JSON response of the unread count endpoint
+type unreadCountResponse struct {
+	UnreadCounts []struct {
+		ID    string `json:"id"`
+		Count int    `json:"count"`
+	} `json:"unreadcounts"`
+}
+
+// SubscriptionList returns all the feeds the user is subscribed to
+func (c *Client) SubscriptionList(ctx context.Context, authToken string) ([]Subscription, error) {
+	if authToken == "" {
+		return nil, fmt.Errorf("auth token is required")
+	}
+
+	var payload subscriptionListResponse
+	if err := c.getJSON(ctx, authToken, "subscription list", "/reader/api/0/subscription/list", nil, &payload); err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]Subscription, 0, len(payload.Subscriptions))
+	for _, s := range payload.Subscriptions {
+		subscription := Subscription{
+			ID:      s.ID,
+			Title:   s.Title,
+			URL:     s.URL,
+			HTMLURL: s.HTMLURL,
+		}
+
+		for _, category := range s.Categories {
+			subscription.Categories = append(subscription.Categories, Category{ID: category.ID, Label: category.Label})
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, nil
+}
+
+// TagList returns all the labels and states available for the user
+func (c *Client) TagList(ctx context.Context, authToken string) ([]Tag, error) {
+	if authToken == "" {
+		return nil, fmt.Errorf("auth token is required")
+	}
+
+	var payload tagListResponse
+	if err := c.getJSON(ctx, authToken, "tag list", "/reader/api/0/tag/list", nil, &payload); err != nil {
+		return nil, err
+	}
+
+	tags := make([]Tag, 0, len(payload.Tags))
+	for _, t := range payload.Tags {
+		tags = append(tags, Tag{ID: t.ID, Type: t.Type})
+	}
+
+	return tags, nil
+}
+
+// UnreadCounts returns the number of unread items of every feed, label and state, indexed by stream ID
+func (c *Client) UnreadCounts(ctx context.Context, authToken string) (map[string]int, error) {
+	if authToken == "" {
+		return nil, fmt.Errorf("auth token is required")
+	}
+
+	var payload unreadCountResponse
+	if err := c.getJSON(ctx, authToken, "unread count", "/reader/api/0/unread-count", nil, &payload); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(payload.UnreadCounts))
+	for _, count := range payload.UnreadCounts {
+		counts[count.ID] = count.Count
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,135 @@
+package client_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+)
+
+func TestSubscriptionList(t *testing.T) {
+	t.Run("WithEmptyAuthToken_ReturnsError", func(t *testing.T) {
+		c := initTestClient(t)
+
+		_, err := c.SubscriptionList(context.Background(), "")
+		require.Error(t, err)
+		assert.Equal(t, err.Error(), "auth token is required")
+	})
+
+	t.Run("WithUnexpectedResponse", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		gock.New("https://freshrss.example.com").
+			Get("/reader/api/0/subscription/list").
+			Reply(500)
+
+		c := initTestClient(t)
+
+		_, err := c.SubscriptionList(context.Background(), "test/auth-token")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "subscription list request failed with unexpected status code 500")
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("WithValidResponse", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		response, err := os.ReadFile("testdata/subscription_list_200.json")
+		require.NoError(t, err)
+
+		gock.New("https://freshrss.example.com").
+			Get("/reader/api/0/subscription/list").
+			MatchHeader("Authorization", "GoogleLogin auth=test/auth-token").
+			MatchParam("output", "json").
+			Reply(200).
+			BodyString(string(response))
+
+		c := initTestClient(t)
+
+		subscriptions, err := c.SubscriptionList(context.Background(), "test/auth-token")
+		require.NoError(t, err)
+		require.Len(t, subscriptions, 2)
+		assert.Equal(t, client.Subscription{
+			ID:         "feed/22",
+			Title:      "Example News",
+			URL:        "https://news.example.com/rss",
+			HTMLURL:    "https://news.example.com",
+			Categories: []client.Category{{ID: "user/-/label/News", Label: "News"}},
+		}, subscriptions[0])
+		assert.True(t, gock.IsDone())
+	})
+}
+
+func TestTagList(t *testing.T) {
+	t.Run("WithEmptyAuthToken_ReturnsError", func(t *testing.T) {
+		c := initTestClient(t)
+
+		_, err := c.TagList(context.Background(), "")
+		require.Error(t, err)
+		assert.Equal(t, err.Error(), "auth token is required")
+	})
+
+	t.Run("WithValidResponse", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		response, err := os.ReadFile("testdata/tag_list_200.json")
+		require.NoError(t, err)
+
+		gock.New("https://freshrss.example.com").
+			Get("/reader/api/0/tag/list").
+			MatchHeader("Authorization", "GoogleLogin auth=test/auth-token").
+			MatchParam("output", "json").
+			Reply(200).
+			BodyString(string(response))
+
+		c := initTestClient(t)
+
+		tags, err := c.TagList(context.Background(), "test/auth-token")
+		require.NoError(t, err)
+		require.Len(t, tags, 3)
+		assert.Equal(t, client.StateStarred, tags[0].ID)
+		assert.Equal(t, client.StateStarred, tags[0].Label())
+		assert.Equal(t, "user/-/label/DevOps", tags[1].ID)
+		assert.Equal(t, "folder", tags[1].Type)
+		assert.Equal(t, "DevOps", tags[1].Label())
+		assert.True(t, gock.IsDone())
+	})
+}
+
+func TestUnreadCounts(t *testing.T) {
+	t.Run("WithEmptyAuthToken_ReturnsError", func(t *testing.T) {
+		c := initTestClient(t)
+
+		_, err := c.UnreadCounts(context.Background(), "")
+		require.Error(t, err)
+		assert.Equal(t, err.Error(), "auth token is required")
+	})
+
+	t.Run("WithValidResponse", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		response, err := os.ReadFile("testdata/unread_count_200.json")
+		require.NoError(t, err)
+
+		gock.New("https://freshrss.example.com").
+			Get("/reader/api/0/unread-count").
+			MatchHeader("Authorization", "GoogleLogin auth=test/auth-token").
+			MatchParam("output", "json").
+			Reply(200).
+			BodyString(string(response))
+
+		c := initTestClient(t)
+
+		counts, err := c.UnreadCounts(context.Background(), "test/auth-token")
+		require.NoError(t, err)
+		assert.Len(t, counts, 5)
+		assert.Equal(t, 40, counts["feed/22"])
+		assert.Equal(t, 12, counts["user/-/label/DevOps"])
+		assert.True(t, gock.IsDone())
+	})
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// badActionTokenHeader is set by FreshRSS on the responses to write requests rejected because of an invalid action token
+const badActionTokenHeader = "X-Reader-Google-Bad-Token"
+
+// ActionToken retrieves a new action token (T token) from the FreshRSS API, required by the write requests.
+// The client requests and caches it automatically, so it is only needed to use the API directly.
+func (c *Client) ActionToken(ctx context.Context, authToken string) (string, error) {
+	if authToken == "" {
+		return "", fmt.Errorf("auth token is required")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/reader/api/0/token", nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating token request: %w", err)
+	}
+
+	c.setAuthHeaders(req, authToken)
+
+	resp, err := c.do("token", req)
+	if err != nil {
+		return "", fmt.Errorf("error executing token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newStatusError("token", resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading token response body: %w", err)
+	}
+
+	token := strings.TrimSpace(string(body))
+	if token == "" {
+		return "", fmt.Errorf("action token not found in response")
+	}
+
+	return token, nil
+}
+
+// cachedActionToken returns the cached action token for the auth token, requesting a new one when there is none yet.
+// When rejected is set to the action token refused by the API, a new one is requested, unless it was already refreshed meanwhile.
+func (c *Client) cachedActionToken(ctx context.Context, authToken string, rejected string) (string, error) {
+	c.actionMu.Lock()
+	defer c.actionMu.Unlock()
+
+	if c.actionToken != "" && c.actionTokenAuth == authToken && c.actionToken != rejected {
+		return c.actionToken, nil
+	}
+
+	token, err := c.ActionToken(ctx, authToken)
+	if err != nil {
+		c.actionToken = ""
+		return "", err
+	}
+
+	c.actionToken = token
+	c.actionTokenAuth = authToken
+
+	return token, nil
+}
+
+// postForm executes an authenticated write request against the specified API path, sending the action token along with the form data.
+// When the action token is rejected, a new one is requested and the request is sent once again.
+// The name is used to identify the request in error messages.
+func (c *Client) postForm(ctx context.Context, authToken string, name string, path string, data url.Values) error {
+	var rejected string
+	for attempt := 0; ; attempt++ {
+		actionToken, err := c.cachedActionToken(ctx, authToken, rejected)
+		if err != nil {
+			return fmt.Errorf("error getting action token for %s request: %w", name, err)
+		}
+		data.Set("T", actionToken)
+
+		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, strings.NewReader(data.Encode()))
+		if err != nil {
+			return fmt.Errorf("error creating %s request: %w", name, err)
+		}
+
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		c.setAuthHeaders(req, authToken)
+
+		resp, err := c.do(name, req)
+		if err != nil {
+			return fmt.Errorf("error executing %s request: %w", name, err)
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			resp.Body.Close()
+			return nil
+		}
+
+		statusErr := newStatusError(name, resp)
+		resp.Body.Close()
+
+		if attempt > 0 || !isActionTokenRejected(resp) {
+			return statusErr
+		}
+
+		rejected = actionToken
+	}
+}
+
+// isActionTokenRejected checks if a write request was rejected because of an invalid or expired action token
+func isActionTokenRejected(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusUnauthorized || resp.Header.Get(badActionTokenHeader) == "true"
+}
@@ -0,0 +1,75 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+)
+
+func TestActionToken(t *testing.T) {
+	t.Run("WithEmptyAuthToken_ReturnsError", func(t *testing.T) {
+		c := initTestClient(t)
+
+		_, err := c.ActionToken(context.Background(), "")
+		assert.EqualError(t, err, "auth token is required")
+	})
+
+	t.Run("WithValidResponse", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		mockActionToken()
+
+		c := initTestClient(t)
+
+		token, err := c.ActionToken(context.Background(), "test/auth-token")
+		require.NoError(t, err)
+		assert.Equal(t, "test-action-token", token)
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("WithUnauthorizedResponse", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		gock.New("https://freshrss.example.com").
+			Get("/reader/api/0/token").
+			Reply(401).
+			BodyString("Unauthorized!")
+
+		c := initTestClient(t)
+
+		_, err := c.ActionToken(context.Background(), "test/auth-token")
+		assert.ErrorIs(t, err, client.ErrUnauthorized)
+		assert.True(t, gock.IsDone())
+	})
+
+	t.Run("RefreshesTheRejectedActionToken", func(t *testing.T) {
+		defer gock.Off() // Flush pending mocks after test execution
+
+		gock.New("https://freshrss.example.com").
+			Get("/reader/api/0/token").
+			Reply(200).
+			BodyString("expired-action-token\n")
+		gock.New("https://freshrss.example.com").
+			Post("/reader/api/0/mark-all-as-read").
+			BodyString("T=expired-action-token&.*").
+			Reply(400).
+			SetHeader("X-Reader-Google-Bad-Token", "true")
+		mockActionToken()
+		gock.New("https://freshrss.example.com").
+			Post("/reader/api/0/mark-all-as-read").
+			BodyString("T=test-action-token&.*").
+			Reply(200)
+
+		c := initTestClient(t)
+
+		err := c.MarkAsRead(context.Background(), "test/auth-token", "feed-id", time.Now())
+		require.NoError(t, err)
+		assert.True(t, gock.IsDone())
+	})
+}
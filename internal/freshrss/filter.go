@@ -0,0 +1,88 @@
+package freshrss
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+)
+
+// fieldMatcher is a compiled regular expression applied to a single field of an item
+type fieldMatcher struct {
+	regex *regexp.Regexp
+	field func(client.Item) string
+}
+
+// itemFilter selects the items to mark as read, based on the match and exclude rules of a feed
+type itemFilter struct {
+	match   []fieldMatcher
+	exclude []fieldMatcher
+}
+
+// newItemFilter compiles the match and exclude rules of the feed configuration
+func newItemFilter(feed config.FeedConfig) (*itemFilter, error) {
+	match, err := compileFieldMatchers(feed.Match)
+	if err != nil {
+		return nil, fmt.Errorf("invalid match rule: %w", err)
+	}
+
+	exclude, err := compileFieldMatchers(feed.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude rule: %w", err)
+	}
+
+	return &itemFilter{match: match, exclude: exclude}, nil
+}
+
+// compileFieldMatchers compiles the regular expressions of the non empty fields of the filter
+func compileFieldMatchers(filter *config.ItemFilter) ([]fieldMatcher, error) {
+	if filter == nil {
+		return nil, nil
+	}
+
+	fields := []struct {
+		name    string
+		pattern string
+		field   func(client.Item) string
+	}{
+		{name: "title", pattern: filter.Title, field: func(i client.Item) string { return i.Title }},
+		{name: "author", pattern: filter.Author, field: func(i client.Item) string { return i.Author }},
+		{name: "url", pattern: filter.URL, field: func(i client.Item) string { return i.URL }},
+		{name: "content", pattern: filter.Content, field: func(i client.Item) string { return i.Content }},
+	}
+
+	var matchers []fieldMatcher
+	for _, f := range fields {
+		if f.pattern == "" {
+			continue
+		}
+
+		regex, err := regexp.Compile(f.pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.name, err)
+		}
+
+		matchers = append(matchers, fieldMatcher{regex: regex, field: f.field})
+	}
+
+	return matchers, nil
+}
+
+// Matches checks if the item should be marked as read.
+// The item must match every regular expression of the match rule and none of the exclude rule.
+func (f *itemFilter) Matches(item client.Item) bool {
+	for _, m := range f.match {
+		if !m.regex.MatchString(m.field(item)) {
+			return false
+		}
+	}
+
+	for _, m := range f.exclude {
+		if m.regex.MatchString(m.field(item)) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,281 @@
+package freshrss
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+)
+
+// Specificity describes how narrow the target of a rule is. When several rules target the same feed, the most specific one applies.
+type Specificity int
+
+// Specificities of the rules, from the least to the most specific
+const (
+	SpecificityAllFeeds Specificity = iota + 1
+	SpecificityPattern
+	SpecificityCategory
+	SpecificityFeed
+)
+
+func (s Specificity) String() string {
+	switch s {
+	case SpecificityAllFeeds:
+		return "all feeds"
+	case SpecificityPattern:
+		return "pattern"
+	case SpecificityCategory:
+		return "category"
+	case SpecificityFeed:
+		return "feed"
+	default:
+		return "unknown"
+	}
+}
+
+// SpecificityOf returns the specificity of the target of the rule
+func SpecificityOf(feed config.FeedConfig) Specificity {
+	switch {
+	case feed.AllFeeds, feed.ID == client.StateReadingList:
+		return SpecificityAllFeeds
+	case feed.IsPattern():
+		return SpecificityPattern
+	case feed.Category != "", strings.HasPrefix(feed.ID, client.LabelPrefix):
+		return SpecificityCategory
+	default:
+		return SpecificityFeed
+	}
+}
+
+// competes checks if the rule competes with the other rules targeting the same feeds, where only one of them applies.
+// Rules with match or exclude filters are meant to be combined with the other rules, and the default rule only applies to the feeds without rules.
+func competes(feed config.FeedConfig) bool {
+	return !feed.IsDefault() && !feed.HasFilters()
+}
+
+// outranks checks if rule a takes precedence over rule b: the rule with the highest priority wins,
+// then the most specific one, and then the first one defined in the configuration
+func outranks(a, b config.FeedConfig) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+
+	if sa, sb := SpecificityOf(a), SpecificityOf(b); sa != sb {
+		return sa > sb
+	}
+
+	return a.Position() < b.Position()
+}
+
+// precedenceReason describes why rule a takes precedence over rule b
+func precedenceReason(a, b config.FeedConfig) string {
+	if a.Priority != b.Priority {
+		return fmt.Sprintf("higher priority (%d > %d)", a.Priority, b.Priority)
+	}
+
+	if sa, sb := SpecificityOf(a), SpecificityOf(b); sa != sb {
+		return fmt.Sprintf("more specific (%s > %s)", sa, sb)
+	}
+
+	return "defined first"
+}
+
+// ruleStreams holds the stream IDs targeted by a rule
+type ruleStreams struct {
+	rule    config.FeedConfig
+	streams []string
+}
+
+// resolveStreams resolves the stream IDs targeted by every rule, except the default one. Rules that can't be resolved target nothing.
+func (c *Catalog) resolveStreams(rules []config.FeedConfig) []ruleStreams {
+	resolved := make([]ruleStreams, 0, len(rules))
+	for _, rule := range rules {
+		if rule.IsDefault() {
+			continue
+		}
+
+		feeds, err := c.Resolve(rule)
+		if err != nil {
+			continue
+		}
+
+		streams := make([]string, 0, len(feeds))
+		for _, f := range feeds {
+			streams = append(streams, f.ID)
+		}
+		resolved = append(resolved, ruleStreams{rule: rule, streams: streams})
+	}
+
+	return resolved
+}
+
+// targets checks if any of the streams of the rule includes the subscription
+func (r ruleStreams) targets(s client.Subscription) bool {
+	for _, stream := range r.streams {
+		if inStream(stream, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inStream checks if the stream includes the subscription: it is its own stream, one of its categories or the reading list
+func inStream(stream string, s client.Subscription) bool {
+	if stream == s.ID || stream == client.StateReadingList {
+		return true
+	}
+
+	for _, category := range s.Categories {
+		if stream == category.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// members returns the IDs of the subscriptions included in the stream, or none when the stream is unknown
+func (c *Catalog) members(stream string) []string {
+	var ids []string
+	for _, s := range c.Subscriptions {
+		if inStream(stream, s) {
+			ids = append(ids, s.ID)
+		}
+	}
+
+	return ids
+}
+
+// winners returns the rule that applies to each subscription targeted by competing rules
+func (c *Catalog) winners(rules []config.FeedConfig) map[string]config.FeedConfig {
+	resolved := c.resolveStreams(rules)
+
+	winners := make(map[string]config.FeedConfig)
+	for _, s := range c.Subscriptions {
+		for _, r := range resolved {
+			if !competes(r.rule) || !r.targets(s) {
+				continue
+			}
+
+			if w, ok := winners[s.ID]; !ok || outranks(r.rule, w) {
+				winners[s.ID] = r.rule
+			}
+		}
+	}
+
+	return winners
+}
+
+// withPrecedence keeps the resolved feeds of the rule where it takes precedence over the other rules.
+// Streams including many feeds, like categories, are split into the feeds the rule still applies to, when other rules take some of them.
+func (c *Catalog) withPrecedence(rule config.FeedConfig, resolved []config.FeedConfig, winners map[string]config.FeedConfig) []config.FeedConfig {
+	kept := make([]config.FeedConfig, 0, len(resolved))
+	for _, f := range resolved {
+		members := c.members(f.ID)
+		if len(members) == 0 {
+			kept = append(kept, f)
+			continue
+		}
+
+		var won []string
+		for _, id := range members {
+			if w, ok := winners[id]; ok && w.Position() == rule.Position() {
+				won = append(won, id)
+			}
+		}
+
+		if len(won) == len(members) {
+			kept = append(kept, f)
+			continue
+		}
+
+		for _, id := range won {
+			split := f
+			split.ID = id
+			kept = append(kept, split)
+		}
+	}
+
+	return kept
+}
+
+// Explanation describes a rule targeting a feed, and whether it applies to it
+type Explanation struct {
+	Rule    config.FeedConfig
+	Applies bool
+	Reason  string
+}
+
+// Explain returns the rules targeting the subscription, in the order they are defined, with whether they apply to it and why.
+// The rules must be the ones returned by config.RootConfig.Rules.
+func (c *Catalog) Explain(rules []config.FeedConfig, s client.Subscription) []Explanation {
+	var matching []config.FeedConfig
+	for _, r := range c.resolveStreams(rules) {
+		if r.targets(s) {
+			matching = append(matching, r.rule)
+		}
+	}
+
+	winner, runnerUp := rank(matching)
+
+	explanations := make([]Explanation, 0, len(matching)+1)
+	for _, rule := range matching {
+		explanations = append(explanations, explainRule(rule, winner, runnerUp))
+	}
+
+	for _, rule := range rules {
+		if !rule.IsDefault() {
+			continue
+		}
+
+		e := Explanation{Rule: rule, Reason: "the feed is targeted by other rules"}
+		if len(matching) == 0 {
+			e.Applies = true
+			e.Reason = "no other rule targets the feed"
+		}
+		explanations = append(explanations, e)
+	}
+
+	return explanations
+}
+
+// rank returns the competing rule taking precedence over the others, and the one it would be replaced by, if any
+func rank(rules []config.FeedConfig) (winner, runnerUp *config.FeedConfig) {
+	for _, rule := range rules {
+		if !competes(rule) {
+			continue
+		}
+
+		rule := rule
+		switch {
+		case winner == nil:
+			winner = &rule
+		case outranks(rule, *winner):
+			runnerUp, winner = winner, &rule
+		case runnerUp == nil || outranks(rule, *runnerUp):
+			runnerUp = &rule
+		}
+	}
+
+	return winner, runnerUp
+}
+
+// explainRule describes whether the rule applies to the feed, given the competing rules ranked first and second
+func explainRule(rule config.FeedConfig, winner, runnerUp *config.FeedConfig) Explanation {
+	e := Explanation{Rule: rule, Applies: true}
+	switch {
+	case !competes(rule):
+		e.Reason = "match and exclude rules are combined with the other rules of the feed"
+	case rule.Position() != winner.Position():
+		e.Applies = false
+		e.Reason = fmt.Sprintf("overridden by %s: %s", winner.Target(), precedenceReason(*winner, rule))
+	case runnerUp == nil:
+		e.Reason = "only rule targeting the feed"
+	default:
+		e.Reason = fmt.Sprintf("takes precedence over %s: %s", runnerUp.Target(), precedenceReason(rule, *runnerUp))
+	}
+
+	return e
+}
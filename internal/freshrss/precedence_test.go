@@ -0,0 +1,145 @@
+package freshrss_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+)
+
+func TestSpecificityOf(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, freshrss.SpecificityFeed, freshrss.SpecificityOf(config.FeedConfig{ID: "feed/1"}))
+	assert.Equal(t, freshrss.SpecificityFeed, freshrss.SpecificityOf(config.FeedConfig{FeedTitle: "Go Blog"}))
+	assert.Equal(t, freshrss.SpecificityCategory, freshrss.SpecificityOf(config.FeedConfig{ID: "user/-/label/News"}))
+	assert.Equal(t, freshrss.SpecificityCategory, freshrss.SpecificityOf(config.FeedConfig{Category: "News"}))
+	assert.Equal(t, freshrss.SpecificityPattern, freshrss.SpecificityOf(config.FeedConfig{FeedsMatching: "*news*", ID: "feed/1"}))
+	assert.Equal(t, freshrss.SpecificityAllFeeds, freshrss.SpecificityOf(config.FeedConfig{AllFeeds: true}))
+	assert.Equal(t, freshrss.SpecificityAllFeeds, freshrss.SpecificityOf(config.FeedConfig{ID: client.StateReadingList}))
+}
+
+func explained(explanations []freshrss.Explanation) [][]string {
+	rows := make([][]string, 0, len(explanations))
+	for _, e := range explanations {
+		status := "overridden"
+		if e.Applies {
+			status = "applies"
+		}
+		rows = append(rows, []string{e.Rule.Target(), status, e.Reason})
+	}
+
+	return rows
+}
+
+func TestCatalog_Explain(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.RootConfig{
+		Default: &config.FeedConfig{Days: 30},
+		Feeds: []config.FeedConfig{
+			{Category: "News", Days: 7},
+			{FeedTitle: "Hacker News", Days: 1},
+			{AllFeeds: true, OlderThan: config.Duration(14 * 24 * time.Hour), ExcludeFeeds: []string{"Go Blog"}},
+			{ID: "feed/1", Match: &config.ItemFilter{Title: "^Ask HN"}},
+		},
+	}
+
+	t.Run("MostSpecificRuleApplies", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, [][]string{
+			{`category="News"`, "overridden", `overridden by feed_title="Hacker News": more specific (feed > category)`},
+			{`feed_title="Hacker News"`, "applies", `takes precedence over category="News": more specific (feed > category)`},
+			{"all_feeds", "overridden", `overridden by feed_title="Hacker News": more specific (feed > all feeds)`},
+			{"feed/1", "applies", "match and exclude rules are combined with the other rules of the feed"},
+			{"default", "overridden", "the feed is targeted by other rules"},
+		}, explained(mockCatalog.Explain(cfg.Rules(), mockCatalog.Subscriptions[0])))
+	})
+
+	t.Run("OnlyRule", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, [][]string{
+			{"all_feeds", "applies", "only rule targeting the feed"},
+			{"default", "overridden", "the feed is targeted by other rules"},
+		}, explained(mockCatalog.Explain(cfg.Rules(), mockCatalog.Subscriptions[3])))
+	})
+
+	t.Run("DefaultRule", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, [][]string{
+			{"default", "applies", "no other rule targets the feed"},
+		}, explained(mockCatalog.Explain(cfg.Rules(), mockCatalog.Subscriptions[1])))
+	})
+
+	t.Run("HighestPriorityApplies", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := &config.RootConfig{
+			Feeds: []config.FeedConfig{
+				{FeedTitle: "Hacker News", Days: 1},
+				{Category: "News", Days: 7, Priority: 5},
+			},
+		}
+
+		assert.Equal(t, [][]string{
+			{`feed_title="Hacker News"`, "overridden", `overridden by category="News": higher priority (5 > 0)`},
+			{`category="News"`, "applies", `takes precedence over feed_title="Hacker News": higher priority (5 > 0)`},
+		}, explained(mockCatalog.Explain(cfg.Rules(), mockCatalog.Subscriptions[0])))
+	})
+}
+
+func TestCleanOldEntries_Precedence(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	c := &mockClient{}
+	ctx := context.Background()
+
+	subscriptions := []client.Subscription{
+		{ID: "feed/1", Title: "Hacker News", Categories: []client.Category{{ID: "user/-/label/News", Label: "News"}}},
+		{ID: "feed/5", Title: "Lobsters", Categories: []client.Category{{ID: "user/-/label/News", Label: "News"}}},
+		{ID: "feed/6", Title: "The Register", Categories: []client.Category{{ID: "user/-/label/News", Label: "News"}}},
+	}
+
+	cleaner, err := freshrss.NewCleaner(
+		freshrss.WithClient(c),
+		freshrss.WithConfig(&config.RootConfig{
+			Protect: config.ProtectConfig{Starred: &disabled},
+			Feeds: []config.FeedConfig{
+				{ID: "user/-/label/News", Days: 7},
+				{ID: "feed/1", Days: 1},
+				{ID: "feed/6", Skip: true},
+			},
+		}),
+	)
+	assert.Nil(t, err)
+
+	c.On("GetAuthToken", ctx).Return("mockToken", nil)
+	c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+	c.On("SubscriptionList", ctx, "mockToken").Return(subscriptions, nil).Once()
+	c.On("TagList", ctx, "mockToken").Return([]client.Tag{}, nil).Once()
+	c.On("MarkAsRead", ctx, "mockToken", "feed/5", mock.Anything).Return(nil).Once()
+	c.On("MarkAsRead", ctx, "mockToken", "feed/1", mock.Anything).Return(nil).Once()
+
+	result, err := cleaner.CleanOldEntries(ctx, logger)
+	assert.Nil(t, err)
+	c.AssertExpectations(t)
+
+	require.Len(t, result.Feeds, 2)
+	assert.Equal(t, "feed/5", result.Feeds[0].FeedID)
+	assert.Equal(t, "days=7", result.Feeds[0].Rule)
+	assert.Equal(t, "feed/1", result.Feeds[1].FeedID)
+	assert.Equal(t, "days=1", result.Feeds[1].Rule)
+}
@@ -0,0 +1,303 @@
+package freshrss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+)
+
+// ErrTargetNotFound is returned when a feed configured by name doesn't match any subscription, category or label
+var ErrTargetNotFound = errors.New("target not found")
+
+// Catalog holds the subscriptions and tags of the server, used to resolve the feeds configured by name into their stream IDs
+type Catalog struct {
+	Subscriptions []client.Subscription
+	Tags          []client.Tag
+}
+
+// NewCatalog fetches the subscriptions and tags of the server
+func NewCatalog(ctx context.Context, api API, authToken string) (*Catalog, error) {
+	subscriptions, err := api.SubscriptionList(ctx, authToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	tags, err := api.TagList(ctx, authToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	return &Catalog{Subscriptions: subscriptions, Tags: tags}, nil
+}
+
+// Resolve returns the feed with its ID set to the stream ID of its target. Feeds configured by ID are returned as they are.
+// Titles and categories are matched case-insensitively, and a title shared by several subscriptions targets all of them.
+// Rules targeting many feeds are expanded to a copy of the feed per matching subscription, which can be none when all of them are excluded.
+func (c *Catalog) Resolve(feed config.FeedConfig) ([]config.FeedConfig, error) {
+	switch {
+	case feed.ID != "":
+		return []config.FeedConfig{feed}, nil
+	case feed.IsPattern():
+		return c.expand(feed)
+	}
+
+	ids := c.streamIDs(feed)
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTargetNotFound, feed.Target())
+	}
+
+	resolved := make([]config.FeedConfig, 0, len(ids))
+	for _, id := range ids {
+		f := feed
+		f.ID = id
+		resolved = append(resolved, f)
+	}
+
+	return resolved, nil
+}
+
+// streamIDs returns the stream IDs of the subscriptions or category the feed is configured by title, URL or category name
+func (c *Catalog) streamIDs(feed config.FeedConfig) []string {
+	var ids []string
+
+	switch {
+	case feed.FeedTitle != "":
+		for _, s := range c.Subscriptions {
+			if strings.EqualFold(s.Title, feed.FeedTitle) {
+				ids = append(ids, s.ID)
+			}
+		}
+	case feed.FeedURL != "":
+		for _, s := range c.Subscriptions {
+			if sameURL(s.URL, feed.FeedURL) {
+				ids = append(ids, s.ID)
+			}
+		}
+	case feed.Category != "":
+		if id, ok := c.categoryID(feed.Category); ok {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// Lookup returns the subscriptions with the specified ID, title or URL. Titles are matched case-insensitively.
+func (c *Catalog) Lookup(name string) []client.Subscription {
+	var found []client.Subscription
+	for _, s := range c.Subscriptions {
+		if s.ID == name || strings.EqualFold(s.Title, name) || sameURL(s.URL, name) {
+			found = append(found, s)
+		}
+	}
+
+	return found
+}
+
+// expand returns a copy of the feed for every subscription matching its patterns, except the excluded ones.
+// It fails when the patterns don't match any subscription, as it is most likely a typo.
+func (c *Catalog) expand(feed config.FeedConfig) ([]config.FeedConfig, error) {
+	var (
+		resolved []config.FeedConfig
+		matched  bool
+	)
+
+	for _, s := range c.Subscriptions {
+		switch {
+		case feed.FeedsMatching != "" && !globMatch(feed.FeedsMatching, s.Title):
+			continue
+		case feed.CategoryMatching != "" && !hasCategoryMatching(s, feed.CategoryMatching):
+			continue
+		}
+
+		matched = true
+		if isExcluded(s, feed) {
+			continue
+		}
+
+		f := feed
+		f.ID = s.ID
+		resolved = append(resolved, f)
+	}
+
+	if !matched {
+		return nil, fmt.Errorf("%w: %s", ErrTargetNotFound, feed.Target())
+	}
+
+	return resolved, nil
+}
+
+// isExcluded checks if the subscription is excluded from the feed, by ID, title or category
+func isExcluded(s client.Subscription, feed config.FeedConfig) bool {
+	for _, pattern := range feed.ExcludeFeeds {
+		if globMatch(pattern, s.ID) || globMatch(pattern, s.Title) {
+			return true
+		}
+	}
+
+	for _, pattern := range feed.ExcludeCategories {
+		if hasCategoryMatching(s, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasCategoryMatching checks if any of the categories of the subscription matches the glob pattern
+func hasCategoryMatching(s client.Subscription, pattern string) bool {
+	for _, category := range s.Categories {
+		if globMatch(pattern, category.Label) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// globMatch checks case-insensitively if the name matches the glob pattern (ex: "*news*"). Invalid patterns never match.
+func globMatch(pattern, name string) bool {
+	re, err := config.CompileGlob(pattern)
+	return err == nil && re.MatchString(name)
+}
+
+// categoryID returns the stream ID of the category or label with the specified name
+func (c *Catalog) categoryID(name string) (string, bool) {
+	for _, t := range c.Tags {
+		if strings.HasPrefix(t.ID, client.LabelPrefix) && strings.EqualFold(t.Label(), name) {
+			return t.ID, true
+		}
+	}
+
+	for _, s := range c.Subscriptions {
+		for _, category := range s.Categories {
+			if strings.EqualFold(category.Label, name) {
+				return category.ID, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// Uncovered returns a copy of the default rule for every subscription not targeted by any of the feeds, including the skipped ones.
+// Subscriptions excluded from a rule targeting many feeds are not targeted by it, so the default rule applies to them.
+func (c *Catalog) Uncovered(defaultRule config.FeedConfig, feeds []config.FeedConfig) []config.FeedConfig {
+	resolved := c.resolveStreams(feeds)
+
+	var uncovered []config.FeedConfig
+	for _, s := range c.Subscriptions {
+		if slices.ContainsFunc(resolved, func(r ruleStreams) bool { return r.targets(s) }) {
+			continue
+		}
+
+		f := defaultRule
+		f.ID = s.ID
+		uncovered = append(uncovered, f)
+	}
+
+	return uncovered
+}
+
+// sameURL compares two feed URLs, ignoring the case of the scheme and host and any trailing slash
+func sameURL(a, b string) bool {
+	return strings.EqualFold(strings.TrimRight(a, "/"), strings.TrimRight(b, "/"))
+}
+
+// needsCatalog checks if any of the rules is configured by name, and must be resolved with the catalog,
+// or if the catalog is needed to find the feeds targeted by several rules
+func needsCatalog(rules []config.FeedConfig) bool {
+	competing := 0
+	overlapping := false
+	for _, rule := range rules {
+		if rule.ID == "" {
+			return true
+		}
+
+		if competes(rule) {
+			competing++
+			overlapping = overlapping || SpecificityOf(rule) != SpecificityFeed
+		}
+	}
+
+	return overlapping && competing > 1
+}
+
+// target is a feed to be processed, resolved to its stream ID, or the error that prevented resolving it
+type target struct {
+	feed config.FeedConfig
+	err  error
+}
+
+// resolveFeeds resolves the feeds configured by name into their stream IDs, fetching the catalog only when needed.
+// When several rules target the same feed, only the one taking precedence is kept for it, as explained by Catalog.Explain.
+// Failures to resolve a feed are returned as a target with an error, so they are reported as failures of that feed only.
+func (c *Cleaner) resolveFeeds(ctx context.Context, authToken string, feeds []config.FeedConfig) []target {
+	var (
+		rules      = c.config.Rules()
+		catalog    *Catalog
+		catalogErr error
+		winners    map[string]config.FeedConfig
+	)
+
+	if needsCatalog(rules) {
+		catalog, catalogErr = NewCatalog(ctx, c.client, authToken)
+	}
+
+	if catalog != nil {
+		winners = catalog.winners(rules)
+	}
+
+	targets := make([]target, 0, len(feeds))
+	for _, feed := range feeds {
+		switch {
+		// Without the catalog, feeds configured by ID are processed as they are, and skipped ones have nothing to resolve
+		case feed.ID != "" && catalog == nil:
+			if !feed.Skip {
+				targets = append(targets, target{feed: feed})
+			}
+		case catalogErr != nil:
+			targets = append(targets, target{feed: feed, err: catalogErr})
+		default:
+			targets = append(targets, catalog.targets(feed, rules, winners)...)
+		}
+	}
+
+	return targets
+}
+
+// targets resolves the rule into the feeds to process. Skip rules are resolved too, so the ones not found are reported,
+// instead of silently leaving the feed they were meant to protect to the default rule.
+func (c *Catalog) targets(feed config.FeedConfig, rules []config.FeedConfig, winners map[string]config.FeedConfig) []target {
+	if feed.IsDefault() {
+		return toTargets(c.Uncovered(feed, rules))
+	}
+
+	resolved, err := c.Resolve(feed)
+	switch {
+	case err != nil:
+		return []target{{feed: feed, err: err}}
+	case feed.Skip:
+		// Skipped feeds are only kept to opt out of other rules
+		return nil
+	case competes(feed):
+		resolved = c.withPrecedence(feed, resolved, winners)
+	}
+
+	return toTargets(resolved)
+}
+
+// toTargets returns the resolved feeds as targets to process
+func toTargets(feeds []config.FeedConfig) []target {
+	targets := make([]target, 0, len(feeds))
+	for _, f := range feeds {
+		targets = append(targets, target{feed: f})
+	}
+
+	return targets
+}
@@ -0,0 +1,266 @@
+package freshrss_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss/client"
+)
+
+var mockCatalog = &freshrss.Catalog{
+	Subscriptions: []client.Subscription{
+		{ID: "feed/1", Title: "Hacker News", URL: "https://news.ycombinator.com/rss", Categories: []client.Category{{ID: "user/-/label/News", Label: "News"}}},
+		{ID: "feed/2", Title: "Go Blog", URL: "https://go.dev/blog/feed.atom"},
+		{ID: "feed/3", Title: "Hacker News", URL: "https://hnrss.org/frontpage"},
+		{ID: "feed/4", Title: "World News", Categories: []client.Category{{ID: "user/-/label/News/World", Label: "News/World"}}},
+	},
+	Tags: []client.Tag{
+		{ID: "user/-/state/com.google/starred"},
+		{ID: "user/-/label/DevOps", Type: "folder"},
+	},
+}
+
+func resolvedIDs(feeds []config.FeedConfig) []string {
+	ids := make([]string, 0, len(feeds))
+	for _, feed := range feeds {
+		ids = append(ids, feed.ID)
+	}
+
+	return ids
+}
+
+func TestCatalog_Resolve(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		feed     config.FeedConfig
+		expected []string
+	}{
+		{name: "ByID", feed: config.FeedConfig{ID: "feed/99"}, expected: []string{"feed/99"}},
+		{name: "ByTitle", feed: config.FeedConfig{FeedTitle: "go blog"}, expected: []string{"feed/2"}},
+		{name: "ByTitleSharedBySeveralFeeds", feed: config.FeedConfig{FeedTitle: "Hacker News"}, expected: []string{"feed/1", "feed/3"}},
+		{name: "ByURL", feed: config.FeedConfig{FeedURL: "https://hnrss.org/frontpage/"}, expected: []string{"feed/3"}},
+		{name: "ByCategoryFromTags", feed: config.FeedConfig{Category: "devops"}, expected: []string{"user/-/label/DevOps"}},
+		{name: "ByCategoryFromSubscriptions", feed: config.FeedConfig{Category: "News"}, expected: []string{"user/-/label/News"}},
+		{name: "FeedsMatching", feed: config.FeedConfig{FeedsMatching: "*NEWS*"}, expected: []string{"feed/1", "feed/3", "feed/4"}},
+		{name: "CategoryMatching", feed: config.FeedConfig{CategoryMatching: "News/*"}, expected: []string{"feed/4"}},
+		{name: "AllFeeds", feed: config.FeedConfig{AllFeeds: true}, expected: []string{"feed/1", "feed/2", "feed/3", "feed/4"}},
+		{name: "AllFeedsExceptExcluded", feed: config.FeedConfig{
+			AllFeeds:          true,
+			ExcludeFeeds:      []string{"feed/3", "go *"},
+			ExcludeCategories: []string{"news/*"},
+		}, expected: []string{"feed/1"}},
+		{name: "AllMatchingFeedsExcluded", feed: config.FeedConfig{FeedsMatching: "Go*", ExcludeFeeds: []string{"*"}}, expected: []string{}},
+	}
+
+	for _, tc := range tests {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			feeds, err := mockCatalog.Resolve(tc.feed)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, resolvedIDs(feeds))
+		})
+	}
+
+	t.Run("KeepsTheRuleOfTheFeed", func(t *testing.T) {
+		t.Parallel()
+
+		feeds, err := mockCatalog.Resolve(config.FeedConfig{FeedTitle: "Go Blog", Days: 7})
+		require.NoError(t, err)
+		assert.Equal(t, []config.FeedConfig{{ID: "feed/2", FeedTitle: "Go Blog", Days: 7}}, feeds)
+	})
+
+	t.Run("PatternsMatchNamesWithSlashes", func(t *testing.T) {
+		t.Parallel()
+
+		catalog := &freshrss.Catalog{
+			Subscriptions: []client.Subscription{
+				{ID: "feed/11", Title: "r/worldnews"},
+				{ID: "feed/12", Title: "BBC News - UK/World"},
+				{ID: "feed/13", Title: "Go Blog", Categories: []client.Category{{ID: "user/-/label/Tech/Go", Label: "Tech/Go"}}},
+			},
+		}
+
+		feeds, err := catalog.Resolve(config.FeedConfig{FeedsMatching: "*news*"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"feed/11", "feed/12"}, resolvedIDs(feeds))
+
+		feeds, err = catalog.Resolve(config.FeedConfig{AllFeeds: true, ExcludeFeeds: []string{"*12"}, ExcludeCategories: []string{"*go"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"feed/11"}, resolvedIDs(feeds))
+	})
+
+	t.Run("WithPatternNotMatchingAnyFeed", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := mockCatalog.Resolve(config.FeedConfig{FeedsMatching: "*sports*"})
+		assert.ErrorIs(t, err, freshrss.ErrTargetNotFound)
+	})
+
+	t.Run("WithUnknownTarget", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := mockCatalog.Resolve(config.FeedConfig{Category: "Sports"})
+		assert.ErrorIs(t, err, freshrss.ErrTargetNotFound)
+		assert.EqualError(t, err, `target not found: category="Sports"`)
+	})
+}
+
+func TestCleanOldEntries_FeedsByName(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	c := &mockClient{}
+	ctx := context.Background()
+
+	cleaner, err := freshrss.NewCleaner(
+		freshrss.WithClient(c),
+		freshrss.WithConfig(&config.RootConfig{
+			Protect: config.ProtectConfig{Starred: &disabled},
+			Feeds: []config.FeedConfig{
+				{FeedTitle: "Go Blog", Days: 7},
+				{Category: "Sports", Days: 7},
+				{ID: "feed/99", Days: 7},
+			},
+		}),
+	)
+	assert.Nil(t, err)
+
+	c.On("GetAuthToken", ctx).Return("mockToken", nil)
+	c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+	c.On("SubscriptionList", ctx, "mockToken").Return(mockCatalog.Subscriptions, nil).Once()
+	c.On("TagList", ctx, "mockToken").Return(mockCatalog.Tags, nil).Once()
+	c.On("MarkAsRead", ctx, "mockToken", "feed/2", mock.Anything).Return(nil).Once()
+	c.On("MarkAsRead", ctx, "mockToken", "feed/99", mock.Anything).Return(nil).Once()
+
+	result, err := cleaner.CleanOldEntries(ctx, logger)
+	assert.Nil(t, err)
+	c.AssertExpectations(t)
+
+	require.Len(t, result.Feeds, 3)
+	assert.Equal(t, "feed/2", result.Feeds[0].FeedID)
+	assert.NoError(t, result.Feeds[0].Err)
+	assert.Equal(t, `category="Sports"`, result.Feeds[1].FeedID)
+	assert.ErrorIs(t, result.Feeds[1].Err, freshrss.ErrTargetNotFound)
+	assert.Equal(t, "feed/99", result.Feeds[2].FeedID)
+}
+
+func TestCatalog_Uncovered(t *testing.T) {
+	t.Parallel()
+
+	defaultRule := config.FeedConfig{Days: 30}
+
+	tests := []struct {
+		name     string
+		feeds    []config.FeedConfig
+		expected []string
+	}{
+		{name: "WithoutFeeds", expected: []string{"feed/1", "feed/2", "feed/3", "feed/4"}},
+		{name: "ByID", feeds: []config.FeedConfig{{ID: "feed/2", Days: 7}}, expected: []string{"feed/1", "feed/3", "feed/4"}},
+		{name: "ByTitle", feeds: []config.FeedConfig{{FeedTitle: "Hacker News", Days: 7}}, expected: []string{"feed/2", "feed/4"}},
+		{name: "ByCategory", feeds: []config.FeedConfig{{Category: "News", Skip: true}}, expected: []string{"feed/2", "feed/3", "feed/4"}},
+		{name: "ExcludedFromPattern", feeds: []config.FeedConfig{{AllFeeds: true, ExcludeFeeds: []string{"go *"}}}, expected: []string{"feed/2"}},
+		{name: "NotFound", feeds: []config.FeedConfig{{FeedTitle: "Sports", Days: 7}}, expected: []string{"feed/1", "feed/2", "feed/3", "feed/4"}},
+		{name: "ReadingList", feeds: []config.FeedConfig{{ID: client.StateReadingList, Days: 7}}, expected: []string{}},
+	}
+
+	for _, tc := range tests {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			feeds := mockCatalog.Uncovered(defaultRule, tc.feeds)
+			assert.Equal(t, tc.expected, resolvedIDs(feeds))
+			for _, feed := range feeds {
+				assert.Equal(t, 30, feed.Days)
+			}
+		})
+	}
+}
+
+func TestCleanOldEntries_DefaultRule(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	c := &mockClient{}
+	ctx := context.Background()
+
+	cleaner, err := freshrss.NewCleaner(
+		freshrss.WithClient(c),
+		freshrss.WithConfig(&config.RootConfig{
+			Protect: config.ProtectConfig{Starred: &disabled},
+			Default: &config.FeedConfig{Days: 30},
+			Feeds: []config.FeedConfig{
+				{ID: "feed/1", Days: 7},
+				{FeedTitle: "Hacker News", Skip: true},
+			},
+		}),
+	)
+	assert.Nil(t, err)
+
+	c.On("GetAuthToken", ctx).Return("mockToken", nil)
+	c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+	c.On("SubscriptionList", ctx, "mockToken").Return(mockCatalog.Subscriptions, nil).Once()
+	c.On("TagList", ctx, "mockToken").Return(mockCatalog.Tags, nil).Once()
+	c.On("MarkAsRead", ctx, "mockToken", "feed/1", mock.Anything).Return(nil).Once()
+	c.On("MarkAsRead", ctx, "mockToken", "feed/2", mock.Anything).Return(nil).Once()
+	c.On("MarkAsRead", ctx, "mockToken", "feed/4", mock.Anything).Return(nil).Once()
+
+	result, err := cleaner.CleanOldEntries(ctx, logger)
+	assert.Nil(t, err)
+	c.AssertExpectations(t)
+
+	require.Len(t, result.Feeds, 3)
+	assert.Equal(t, "feed/1", result.Feeds[0].FeedID)
+	assert.Equal(t, "days=7", result.Feeds[0].Rule)
+	assert.Equal(t, "feed/2", result.Feeds[1].FeedID)
+	assert.Equal(t, "default, days=30", result.Feeds[1].Rule)
+	assert.Equal(t, "feed/4", result.Feeds[2].FeedID)
+}
+
+func TestCleanOldEntries_UnresolvedSkipRule(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	c := &mockClient{}
+	ctx := context.Background()
+
+	cleaner, err := freshrss.NewCleaner(
+		freshrss.WithClient(c),
+		freshrss.WithConfig(&config.RootConfig{
+			Protect: config.ProtectConfig{Starred: &disabled},
+			Default: &config.FeedConfig{Days: 30},
+			Feeds: []config.FeedConfig{
+				{FeedTitle: "Hacker Nwes", Skip: true},
+			},
+		}),
+	)
+	assert.Nil(t, err)
+
+	c.On("GetAuthToken", ctx).Return("mockToken", nil)
+	c.On("UnreadCounts", ctx, "mockToken").Return(map[string]int{}, nil)
+	c.On("SubscriptionList", ctx, "mockToken").Return(mockCatalog.Subscriptions, nil).Once()
+	c.On("TagList", ctx, "mockToken").Return(mockCatalog.Tags, nil).Once()
+	c.On("MarkAsRead", ctx, "mockToken", mock.Anything, mock.Anything).Return(nil).Times(4)
+
+	result, err := cleaner.CleanOldEntries(ctx, logger)
+	assert.Nil(t, err)
+	c.AssertExpectations(t)
+
+	require.Len(t, result.Feeds, 5)
+	assert.Equal(t, `feed_title="Hacker Nwes"`, result.Feeds[0].FeedID)
+	assert.Equal(t, "skip", result.Feeds[0].Rule)
+	assert.ErrorIs(t, result.Feeds[0].Err, freshrss.ErrTargetNotFound)
+	assert.Equal(t, 1, result.Failed())
+}
@@ -0,0 +1,60 @@
+package freshrss
+
+import (
+	"time"
+)
+
+// FeedResult contains the outcome of processing a single feed rule
+type FeedResult struct {
+	FeedID string
+	// Rule describes the rule applied to the feed (ex: "older_than=7d, keep_unread=50")
+	Rule string
+	// UnreadBefore and UnreadAfter are the number of unread items of the feed before and after the run
+	UnreadBefore int
+	UnreadAfter  int
+	// Marked is the number of items marked as read, or that would be marked as read in dry-run mode
+	Marked int
+	// Spared is the number of items that were not marked as read because they are protected
+	Spared   int
+	Duration time.Duration
+	Err      error
+
+	// runID identifies the run in the journal
+	runID string
+	// position is the position in the configuration of the rule the feed was processed for
+	position int
+	// markedFromUnreadCounts is set when items were marked with mark-all-as-read, which doesn't report the number of items
+	markedFromUnreadCounts bool
+	// reported holds the IDs of the items reported in dry-run mode, which are still unread and must not be counted twice
+	reported map[string]bool
+}
+
+// Result contains the outcome of a cleaner run
+type Result struct {
+	// RunID identifies the run, for example to undo it with the journal
+	RunID    string
+	Feeds    []FeedResult
+	Duration time.Duration
+}
+
+// Failed returns the number of feeds that failed to be processed
+func (r *Result) Failed() int {
+	failed := 0
+	for _, feed := range r.Feeds {
+		if feed.Err != nil {
+			failed++
+		}
+	}
+
+	return failed
+}
+
+// Marked returns the total number of items marked as read
+func (r *Result) Marked() int {
+	marked := 0
+	for _, feed := range r.Feeds {
+		marked += feed.Marked
+	}
+
+	return marked
+}
@@ -0,0 +1,263 @@
+// Package journal records the items marked as read by the cleaner runs, so they can be marked as unread again.
+package journal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dirname is the name of the directory storing the journals, inside the user data directory
+const dirname = "freshrss-cleaner"
+
+// extension is the extension of the journal files, one per run
+const extension = ".jsonl"
+
+// DefaultKeepRuns is the default number of runs kept in the journal
+const DefaultKeepRuns = 50
+
+// ErrRunNotFound is returned when the journal has no entries for a run
+var ErrRunNotFound = errors.New("run not found in journal")
+
+// Entry is an item marked as read by a run
+type Entry struct {
+	FeedID   string    `json:"feed_id"`
+	ItemID   string    `json:"item_id"`
+	MarkedAt time.Time `json:"marked_at"`
+}
+
+// Run summarizes the items marked as read by a run
+type Run struct {
+	ID    string
+	Time  time.Time
+	Items int
+	Feeds int
+}
+
+// FileJournal stores the items marked as read by each run of a FreshRSS account in a JSON Lines file, only readable by the current user
+type FileJournal struct {
+	dir      string
+	keepRuns int
+
+	mu sync.Mutex
+}
+
+// DefaultDir returns the default directory of the journals, in the user data directory ($XDG_DATA_HOME or ~/.local/share)
+func DefaultDir() (string, error) {
+	if dataDir := os.Getenv("XDG_DATA_HOME"); dataDir != "" {
+		return filepath.Join(dataDir, dirname), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".local", "share", dirname), nil
+}
+
+// New creates a journal for the account of the specified username on the FreshRSS instance at url, stored in dir.
+// Each account is stored in its own directory, named after a hash of the URL and username. Only the last keepRuns runs are kept,
+// or DefaultKeepRuns when it is zero.
+func New(dir string, url string, username string, keepRuns int) *FileJournal {
+	hash := sha256.Sum256([]byte(url + "\x00" + username))
+
+	if keepRuns <= 0 {
+		keepRuns = DefaultKeepRuns
+	}
+
+	return &FileJournal{
+		dir:      filepath.Join(dir, "journal-"+hex.EncodeToString(hash[:])),
+		keepRuns: keepRuns,
+	}
+}
+
+// Dir returns the directory of the journal files
+func (j *FileJournal) Dir() string {
+	return j.dir
+}
+
+// Record appends the items marked as read in the feed to the journal of the run.
+// Recording the first items of a run removes the oldest runs beyond the number of runs to keep.
+func (j *FileJournal) Record(runID string, feedID string, itemIDs []string) error {
+	path, err := j.path(runID)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := os.MkdirAll(j.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	_, err = os.Stat(path)
+	newRun := errors.Is(err, fs.ErrNotExist)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open journal file: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	now := time.Now().UTC()
+	for _, id := range itemIDs {
+		if err := encoder.Encode(Entry{FeedID: feedID, ItemID: id, MarkedAt: now}); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write journal file: %w", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write journal file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write journal file: %w", err)
+	}
+
+	if newRun {
+		return j.prune()
+	}
+
+	return nil
+}
+
+// Entries returns the items marked as read by the run, in the order they were recorded
+func (j *FileJournal) Entries(runID string) ([]Entry, error) {
+	path, err := j.path(runID)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrRunNotFound, runID)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal file: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse journal file %s at line %d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal file: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Runs returns the runs recorded in the journal, newest first
+func (j *FileJournal) Runs() ([]Run, error) {
+	ids, err := j.runIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]Run, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		entries, err := j.Entries(ids[i])
+		if err != nil {
+			return nil, err
+		}
+
+		run := Run{ID: ids[i], Items: len(entries)}
+		feeds := make(map[string]bool)
+		for _, entry := range entries {
+			feeds[entry.FeedID] = true
+			if entry.MarkedAt.After(run.Time) {
+				run.Time = entry.MarkedAt
+			}
+		}
+		run.Feeds = len(feeds)
+
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
+// Remove deletes the journal of the run, for example once it was undone
+func (j *FileJournal) Remove(runID string) error {
+	path, err := j.path(runID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove journal file: %w", err)
+	}
+
+	return nil
+}
+
+// prune removes the oldest runs beyond the number of runs to keep
+func (j *FileJournal) prune() error {
+	ids, err := j.runIDs()
+	if err != nil {
+		return err
+	}
+
+	for len(ids) > j.keepRuns {
+		if err := os.Remove(filepath.Join(j.dir, ids[0]+extension)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove journal file: %w", err)
+		}
+		ids = ids[1:]
+	}
+
+	return nil
+}
+
+// runIDs returns the IDs of the runs recorded in the journal, oldest first. Run IDs sort in the order they were created.
+func (j *FileJournal) runIDs() ([]string, error) {
+	files, err := os.ReadDir(j.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal directory: %w", err)
+	}
+
+	var ids []string
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), extension) {
+			ids = append(ids, strings.TrimSuffix(file.Name(), extension))
+		}
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// path returns the path of the journal file of the run, rejecting run IDs that would point outside the journal directory
+func (j *FileJournal) path(runID string) (string, error) {
+	if runID == "" || runID != filepath.Base(runID) || strings.HasPrefix(runID, ".") {
+		return "", fmt.Errorf("invalid run ID %q", runID)
+	}
+
+	return filepath.Join(j.dir, runID+extension), nil
+}
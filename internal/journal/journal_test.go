@@ -0,0 +1,116 @@
+package journal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brpaz/freshrss-cleaner/internal/journal"
+)
+
+func TestFileJournal(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Records and returns the entries of a run", func(t *testing.T) {
+		t.Parallel()
+		j := journal.New(filepath.Join(t.TempDir(), "data"), "https://example.com", "user", 0)
+
+		require.NoError(t, j.Record("20240102-150405-1a2b", "feed/1", []string{"item1", "item2"}))
+		require.NoError(t, j.Record("20240102-150405-1a2b", "user/-/label/News", []string{"item3"}))
+
+		info, err := os.Stat(filepath.Join(j.Dir(), "20240102-150405-1a2b.jsonl"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		entries, err := j.Entries("20240102-150405-1a2b")
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, "feed/1", entries[0].FeedID)
+		assert.Equal(t, "item1", entries[0].ItemID)
+		assert.False(t, entries[0].MarkedAt.IsZero())
+		assert.Equal(t, "user/-/label/News", entries[2].FeedID)
+		assert.Equal(t, "item3", entries[2].ItemID)
+	})
+
+	t.Run("Lists the runs newest first", func(t *testing.T) {
+		t.Parallel()
+		j := journal.New(t.TempDir(), "https://example.com", "user", 0)
+
+		runs, err := j.Runs()
+		require.NoError(t, err)
+		assert.Empty(t, runs)
+
+		require.NoError(t, j.Record("20240102-150405-1a2b", "feed/1", []string{"item1", "item2"}))
+		require.NoError(t, j.Record("20240103-150405-0f0f", "feed/1", []string{"item3"}))
+		require.NoError(t, j.Record("20240103-150405-0f0f", "feed/2", []string{"item4"}))
+
+		runs, err = j.Runs()
+		require.NoError(t, err)
+		require.Len(t, runs, 2)
+		assert.Equal(t, "20240103-150405-0f0f", runs[0].ID)
+		assert.Equal(t, 2, runs[0].Items)
+		assert.Equal(t, 2, runs[0].Feeds)
+		assert.Equal(t, "20240102-150405-1a2b", runs[1].ID)
+		assert.Equal(t, 2, runs[1].Items)
+		assert.Equal(t, 1, runs[1].Feeds)
+	})
+
+	t.Run("Keeps only the last runs", func(t *testing.T) {
+		t.Parallel()
+		j := journal.New(t.TempDir(), "https://example.com", "user", 2)
+
+		for _, runID := range []string{"20240101-000000-0000", "20240102-000000-0000", "20240103-000000-0000"} {
+			require.NoError(t, j.Record(runID, "feed/1", []string{"item"}))
+		}
+
+		runs, err := j.Runs()
+		require.NoError(t, err)
+		require.Len(t, runs, 2)
+		assert.Equal(t, "20240103-000000-0000", runs[0].ID)
+		assert.Equal(t, "20240102-000000-0000", runs[1].ID)
+	})
+
+	t.Run("Removes a run", func(t *testing.T) {
+		t.Parallel()
+		j := journal.New(t.TempDir(), "https://example.com", "user", 0)
+
+		require.NoError(t, j.Record("20240102-150405-1a2b", "feed/1", []string{"item1"}))
+		require.NoError(t, j.Remove("20240102-150405-1a2b"))
+
+		_, err := j.Entries("20240102-150405-1a2b")
+		assert.ErrorIs(t, err, journal.ErrRunNotFound)
+		require.NoError(t, j.Remove("20240102-150405-1a2b"), "removing a missing run must not fail")
+	})
+
+	t.Run("Uses a different directory per account", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+
+		assert.NotEqual(t,
+			journal.New(dir, "https://example.com", "user", 0).Dir(),
+			journal.New(dir, "https://example.com", "other", 0).Dir(),
+		)
+	})
+
+	t.Run("Rejects invalid run IDs", func(t *testing.T) {
+		t.Parallel()
+		j := journal.New(t.TempDir(), "https://example.com", "user", 0)
+
+		for _, runID := range []string{"", "../token", "runs/1", ".hidden"} {
+			_, err := j.Entries(runID)
+			assert.ErrorContains(t, err, "invalid run ID")
+			assert.ErrorContains(t, j.Record(runID, "feed/1", []string{"item"}), "invalid run ID")
+		}
+	})
+}
+
+func TestDefaultDir(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "/tmp/data")
+
+	dir, err := journal.DefaultDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/tmp/data", "freshrss-cleaner"), dir)
+}
@@ -0,0 +1,53 @@
+// Package logging creates the application logger, and passes it to the commands through their context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// Supported log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// New creates a logger writing the records at or above the level (debug, info, warn or error) to w, in the text or json format
+func New(w io.Writer, format string, level string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: must be one of debug, info, warn or error", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: l}
+
+	switch format {
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q: must be one of %s or %s", format, FormatText, FormatJSON)
+	}
+}
+
+// contextKey is the key of the logger in the context
+type contextKey struct{}
+
+// NewContext returns a copy of the context carrying the logger
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by the context, or a logger writing text records at or above the info level
+// to the standard error when there is none
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+
+	return slog.New(slog.NewTextHandler(os.Stderr, nil))
+}
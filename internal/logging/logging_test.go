@@ -0,0 +1,69 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brpaz/freshrss-cleaner/internal/logging"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	t.Run("With json format", func(t *testing.T) {
+		t.Parallel()
+		var b bytes.Buffer
+
+		logger, err := logging.New(&b, "json", "warn")
+		require.NoError(t, err)
+
+		logger.Info("ignored")
+		logger.Warn("Feed failed", "feed_id", "feed/1")
+
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(b.Bytes(), &record))
+		assert.Equal(t, "WARN", record["level"])
+		assert.Equal(t, "Feed failed", record["msg"])
+		assert.Equal(t, "feed/1", record["feed_id"])
+	})
+
+	t.Run("With text format", func(t *testing.T) {
+		t.Parallel()
+		var b bytes.Buffer
+
+		logger, err := logging.New(&b, "text", "DEBUG")
+		require.NoError(t, err)
+
+		logger.Debug("API request", "request", "auth")
+		assert.Contains(t, b.String(), `level=DEBUG msg="API request" request=auth`)
+	})
+
+	t.Run("With invalid format", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := logging.New(&bytes.Buffer{}, "xml", "info")
+		assert.EqualError(t, err, `invalid log format "xml": must be one of text or json`)
+	})
+
+	t.Run("With invalid level", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := logging.New(&bytes.Buffer{}, "text", "verbose")
+		assert.EqualError(t, err, `invalid log level "verbose": must be one of debug, info, warn or error`)
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	assert.NotNil(t, logging.FromContext(context.Background()))
+
+	logger, err := logging.New(&bytes.Buffer{}, "json", "info")
+	require.NoError(t, err)
+	assert.Same(t, logger, logging.FromContext(logging.NewContext(context.Background(), logger)))
+}
@@ -0,0 +1,90 @@
+// Package metrics provides Prometheus metrics about the cleaner runs and the FreshRSS API requests.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss"
+)
+
+const namespace = "freshrss_cleaner"
+
+// Metrics collects the metrics of the cleaner. It implements both the freshrss.Observer and client.RequestObserver interfaces.
+type Metrics struct {
+	registry        *prometheus.Registry
+	itemsMarkedRead *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	authFailures    prometheus.Counter
+	lastSuccess     *prometheus.GaugeVec
+}
+
+// New creates the metrics, registered in their own registry
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		itemsMarkedRead: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "items_marked_read_total",
+			Help:      "Number of items marked as read, per feed.",
+		}, []string{"feed"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "api_request_duration_seconds",
+			Help:      "Duration of the FreshRSS API requests, per endpoint and status code. The status is 0 when no response was received.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint", "status"}),
+		authFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "auth_failures_total",
+			Help:      "Number of failures to authenticate against the FreshRSS API.",
+		}),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_success_timestamp_seconds",
+			Help:      "Unix timestamp of the last successful run, per rule, identified by its target.",
+		}, []string{"rule"}),
+	}
+
+	m.registry.MustRegister(
+		m.itemsMarkedRead,
+		m.requestDuration,
+		m.authFailures,
+		m.lastSuccess,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// Handler returns the HTTP handler exposing the metrics in the Prometheus format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records the duration and status of a FreshRSS API request
+func (m *Metrics) ObserveRequest(name string, statusCode int, duration time.Duration) {
+	m.requestDuration.WithLabelValues(name, strconv.Itoa(statusCode)).Observe(duration.Seconds())
+}
+
+// ObserveAuthFailure records a failure to authenticate against the FreshRSS API
+func (m *Metrics) ObserveAuthFailure() {
+	m.authFailures.Inc()
+}
+
+// ObserveFeedResult records the items marked as read in a feed
+func (m *Metrics) ObserveFeedResult(result freshrss.FeedResult) {
+	m.itemsMarkedRead.WithLabelValues(result.FeedID).Add(float64(result.Marked))
+}
+
+// ObserveRuleResult records the time of the run when the rule succeeded
+func (m *Metrics) ObserveRuleResult(rule string, succeeded bool) {
+	if succeeded {
+		m.lastSuccess.WithLabelValues(rule).SetToCurrentTime()
+	}
+}
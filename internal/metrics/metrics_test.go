@@ -0,0 +1,42 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss"
+	"github.com/brpaz/freshrss-cleaner/internal/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	m := metrics.New()
+	m.ObserveRequest("edit-tag", 200, 150*time.Millisecond)
+	m.ObserveAuthFailure()
+	m.ObserveFeedResult(freshrss.FeedResult{FeedID: "feed/1", Marked: 5})
+	m.ObserveFeedResult(freshrss.FeedResult{FeedID: "feed/1", Marked: 2})
+	m.ObserveFeedResult(freshrss.FeedResult{FeedID: "feed/2", Err: assert.AnError})
+	m.ObserveRuleResult(`category="News"`, true)
+	m.ObserveRuleResult("feed/2", false)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(body), `freshrss_cleaner_items_marked_read_total{feed="feed/1"} 7`)
+	assert.Contains(t, string(body), `freshrss_cleaner_items_marked_read_total{feed="feed/2"} 0`)
+	assert.Contains(t, string(body), `freshrss_cleaner_api_request_duration_seconds_count{endpoint="edit-tag",status="200"} 1`)
+	assert.Contains(t, string(body), `freshrss_cleaner_auth_failures_total 1`)
+	assert.Contains(t, string(body), `freshrss_cleaner_last_success_timestamp_seconds{rule="category=\"News\""}`)
+	assert.NotContains(t, string(body), `freshrss_cleaner_last_success_timestamp_seconds{rule="feed/2"}`)
+}
@@ -0,0 +1,187 @@
+// Package scheduler provides a way to run the cleaner periodically, according to cron expressions.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss"
+)
+
+// Runner defines the interface to run the cleaner for a subset of the configured feeds
+type Runner interface {
+	CleanFeeds(ctx context.Context, log *slog.Logger, feeds []config.FeedConfig) (*freshrss.Result, error)
+}
+
+// Job represents a group of feeds that share the same schedule
+type Job struct {
+	Schedule string
+	Feeds    []config.FeedConfig
+}
+
+// Scheduler runs the cleaner for each job, according to its schedule
+type Scheduler struct {
+	runner          Runner
+	feeds           []config.FeedConfig
+	defaultSchedule string
+	log             *slog.Logger
+}
+
+// Option defines a function to configure the scheduler
+type Option func(*Scheduler)
+
+// WithRunner sets the runner executed on schedule, usually a freshrss.Cleaner
+func WithRunner(runner Runner) Option {
+	return func(s *Scheduler) {
+		s.runner = runner
+	}
+}
+
+// WithFeeds sets the feeds to be scheduled
+func WithFeeds(feeds []config.FeedConfig) Option {
+	return func(s *Scheduler) {
+		s.feeds = feeds
+	}
+}
+
+// WithDefaultSchedule sets the cron expression used for the feeds that don't define their own schedule
+func WithDefaultSchedule(schedule string) Option {
+	return func(s *Scheduler) {
+		s.defaultSchedule = schedule
+	}
+}
+
+// WithLogger sets the logger used by the scheduler and passed to the runner
+func WithLogger(log *slog.Logger) Option {
+	return func(s *Scheduler) {
+		s.log = log
+	}
+}
+
+// Validate checks if the scheduler is properly configured and every schedule is a valid cron expression
+func (s *Scheduler) Validate() error {
+	if s.runner == nil {
+		return fmt.Errorf("runner is required")
+	}
+
+	if s.log == nil {
+		return fmt.Errorf("logger is required")
+	}
+
+	if len(s.feeds) == 0 {
+		return fmt.Errorf("at least one feed is required")
+	}
+
+	if _, err := s.Jobs(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// New creates a new scheduler with the provided options
+func New(opts ...Option) (*Scheduler, error) {
+	s := &Scheduler{}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scheduler configuration: %w", err)
+	}
+
+	return s, nil
+}
+
+// Jobs groups the feeds by schedule, in the order their schedule first appears in the configuration.
+// Feeds without their own schedule use the default schedule.
+func (s *Scheduler) Jobs() ([]Job, error) {
+	var jobs []Job
+	index := map[string]int{}
+
+	for _, feed := range s.feeds {
+		schedule := feed.Schedule
+		if schedule == "" {
+			schedule = s.defaultSchedule
+		}
+
+		if schedule == "" {
+			return nil, fmt.Errorf("feed %s has no schedule and no default schedule is set", feed.Target())
+		}
+
+		if _, err := cron.ParseStandard(schedule); err != nil {
+			return nil, fmt.Errorf("invalid schedule %q for feed %s: %w", schedule, feed.Target(), err)
+		}
+
+		i, ok := index[schedule]
+		if !ok {
+			i = len(jobs)
+			index[schedule] = i
+			jobs = append(jobs, Job{Schedule: schedule})
+		}
+
+		jobs[i].Feeds = append(jobs[i].Feeds, feed)
+	}
+
+	return jobs, nil
+}
+
+// Run starts running the jobs on schedule and blocks until the context is cancelled.
+// A job is skipped when its previous run is still in progress. On exit, it waits for the running jobs to finish.
+func (s *Scheduler) Run(ctx context.Context) error {
+	jobs, err := s.Jobs()
+	if err != nil {
+		return err
+	}
+
+	c := cron.New()
+	for _, job := range jobs {
+		job := job
+		var running sync.Mutex
+
+		if _, err := c.AddFunc(job.Schedule, func() { s.runJob(ctx, job, &running) }); err != nil {
+			return fmt.Errorf("failed to schedule job %q: %w", job.Schedule, err)
+		}
+
+		s.log.Info("Scheduled feeds", "schedule", job.Schedule, "feeds", len(job.Feeds))
+	}
+
+	c.Start()
+	<-ctx.Done()
+
+	s.log.Info("Stopping scheduler, waiting for running jobs to finish")
+	<-c.Stop().Done()
+
+	return nil
+}
+
+// runJob runs the cleaner for the feeds of the job, unless the previous run is still in progress
+func (s *Scheduler) runJob(ctx context.Context, job Job, running *sync.Mutex) {
+	if !running.TryLock() {
+		s.log.Warn("Skipping run, the previous one is still in progress", "schedule", job.Schedule)
+		return
+	}
+	defer running.Unlock()
+
+	s.log.Info("Starting scheduled run", "schedule", job.Schedule, "feeds", len(job.Feeds))
+
+	result, err := s.runner.CleanFeeds(ctx, s.log, job.Feeds)
+	if err != nil {
+		s.log.Error("Scheduled run failed", "schedule", job.Schedule, "error", err)
+		return
+	}
+
+	s.log.Info("Scheduled run finished",
+		"schedule", job.Schedule,
+		"run_id", result.RunID,
+		"marked", result.Marked(),
+		"failed", result.Failed(),
+		"duration", result.Duration,
+	)
+}
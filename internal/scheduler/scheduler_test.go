@@ -0,0 +1,160 @@
+package scheduler_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brpaz/freshrss-cleaner/internal/config"
+	"github.com/brpaz/freshrss-cleaner/internal/freshrss"
+	"github.com/brpaz/freshrss-cleaner/internal/scheduler"
+)
+
+// runnerFunc implements the scheduler.Runner interface with a function
+type runnerFunc func(ctx context.Context, log *slog.Logger, feeds []config.FeedConfig) (*freshrss.Result, error)
+
+func (f runnerFunc) CleanFeeds(ctx context.Context, log *slog.Logger, feeds []config.FeedConfig) (*freshrss.Result, error) {
+	return f(ctx, log, feeds)
+}
+
+var (
+	noopRunner = runnerFunc(func(ctx context.Context, log *slog.Logger, feeds []config.FeedConfig) (*freshrss.Result, error) {
+		return &freshrss.Result{}, nil
+	})
+	logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		feeds           []config.FeedConfig
+		defaultSchedule string
+		errorMsg        string
+	}{
+		{
+			name:            "WithValidConfigs",
+			feeds:           []config.FeedConfig{{ID: "feed1"}, {ID: "feed2", Schedule: "@every 10m"}},
+			defaultSchedule: "0 */2 * * *",
+		},
+		{
+			name:     "WithoutFeeds",
+			errorMsg: "at least one feed is required",
+		},
+		{
+			name:     "WithoutSchedule",
+			feeds:    []config.FeedConfig{{ID: "feed1"}},
+			errorMsg: "feed feed1 has no schedule and no default schedule is set",
+		},
+		{
+			name:     "WithoutSchedule_ForRuleWithoutID",
+			feeds:    []config.FeedConfig{{Category: "News"}},
+			errorMsg: `feed category="News" has no schedule and no default schedule is set`,
+		},
+		{
+			name:            "WithInvalidSchedule_ForRuleWithoutID",
+			feeds:           []config.FeedConfig{{FeedTitle: "Hacker News", Schedule: "every day"}},
+			defaultSchedule: "@hourly",
+			errorMsg:        `invalid schedule "every day" for feed feed_title="Hacker News"`,
+		},
+		{
+			name:            "WithInvalidSchedule",
+			feeds:           []config.FeedConfig{{ID: "feed1", Schedule: "every day"}},
+			defaultSchedule: "@hourly",
+			errorMsg:        `invalid schedule "every day" for feed feed1`,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := scheduler.New(
+				scheduler.WithRunner(noopRunner),
+				scheduler.WithLogger(logger),
+				scheduler.WithFeeds(tc.feeds),
+				scheduler.WithDefaultSchedule(tc.defaultSchedule),
+			)
+
+			if tc.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorMsg)
+				assert.Nil(t, s)
+			} else {
+				require.NoError(t, err)
+				assert.IsType(t, &scheduler.Scheduler{}, s)
+			}
+		})
+	}
+}
+
+func TestJobs(t *testing.T) {
+	t.Parallel()
+
+	s, err := scheduler.New(
+		scheduler.WithRunner(noopRunner),
+		scheduler.WithLogger(logger),
+		scheduler.WithDefaultSchedule("@hourly"),
+		scheduler.WithFeeds([]config.FeedConfig{
+			{ID: "feed1"},
+			{ID: "feed2", Schedule: "*/10 * * * *"},
+			{ID: "feed3"},
+			{ID: "feed4", Schedule: "@hourly"},
+		}),
+	)
+	require.NoError(t, err)
+
+	jobs, err := s.Jobs()
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+
+	assert.Equal(t, "@hourly", jobs[0].Schedule)
+	assert.Equal(t, []config.FeedConfig{{ID: "feed1"}, {ID: "feed3"}, {ID: "feed4", Schedule: "@hourly"}}, jobs[0].Feeds)
+	assert.Equal(t, "*/10 * * * *", jobs[1].Schedule)
+	assert.Equal(t, []config.FeedConfig{{ID: "feed2", Schedule: "*/10 * * * *"}}, jobs[1].Feeds)
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	runs := make(chan []config.FeedConfig, 1)
+	runner := runnerFunc(func(ctx context.Context, log *slog.Logger, feeds []config.FeedConfig) (*freshrss.Result, error) {
+		select {
+		case runs <- feeds:
+		default:
+		}
+		return &freshrss.Result{}, nil
+	})
+
+	feeds := []config.FeedConfig{{ID: "feed1"}}
+	s, err := scheduler.New(
+		scheduler.WithRunner(runner),
+		scheduler.WithLogger(logger),
+		scheduler.WithFeeds(feeds),
+		scheduler.WithDefaultSchedule("@every 1s"),
+	)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	select {
+	case ran := <-runs:
+		assert.Equal(t, feeds, ran)
+	case <-ctx.Done():
+		t.Fatal("scheduled job did not run")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}
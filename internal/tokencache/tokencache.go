@@ -0,0 +1,98 @@
+// Package tokencache persists the FreshRSS auth tokens between runs, so the cleaner doesn't need to log in every time.
+package tokencache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// dirname is the name of the directory storing the tokens, inside the user cache directory
+const dirname = "freshrss-cleaner"
+
+// FileCache stores the auth token of a FreshRSS account in a file, only readable by the current user
+type FileCache struct {
+	path string
+}
+
+// DefaultDir returns the default directory of the token cache files, in the user cache directory
+func DefaultDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %w", err)
+	}
+
+	return filepath.Join(cacheDir, dirname), nil
+}
+
+// New creates a token cache for the account of the specified username on the FreshRSS instance at url, stored in dir.
+// Each account is stored in its own file, named after a hash of the URL and username.
+func New(dir string, url string, username string) *FileCache {
+	hash := sha256.Sum256([]byte(url + "\x00" + username))
+
+	return &FileCache{
+		path: filepath.Join(dir, "token-"+hex.EncodeToString(hash[:])),
+	}
+}
+
+// Path returns the path of the cache file
+func (c *FileCache) Path() string {
+	return c.path
+}
+
+// Load returns the cached token, or an empty string when there is none
+func (c *FileCache) Load() (string, error) {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read token cache file: %w", err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Save stores the token in the cache file, replacing any previous one
+func (c *FileCache) Save(token string) error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create token cache directory: %w", err)
+	}
+
+	// Write to a temporary file first, so concurrent runs never read a partially written token
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create token cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(token); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token cache file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write token cache file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to write token cache file: %w", err)
+	}
+
+	return nil
+}
+
+// Clear removes the cached token
+func (c *FileCache) Clear() error {
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove token cache file: %w", err)
+	}
+
+	return nil
+}
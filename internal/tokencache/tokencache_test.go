@@ -0,0 +1,57 @@
+package tokencache_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brpaz/freshrss-cleaner/internal/tokencache"
+)
+
+func TestFileCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Returns an empty token when nothing is cached", func(t *testing.T) {
+		t.Parallel()
+		c := tokencache.New(t.TempDir(), "https://example.com", "user")
+
+		token, err := c.Load()
+		require.NoError(t, err)
+		assert.Empty(t, token)
+	})
+
+	t.Run("Saves, loads and clears the token", func(t *testing.T) {
+		t.Parallel()
+		dir := filepath.Join(t.TempDir(), "cache")
+		c := tokencache.New(dir, "https://example.com", "user")
+
+		require.NoError(t, c.Save("user/token"))
+
+		info, err := os.Stat(c.Path())
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		token, err := c.Load()
+		require.NoError(t, err)
+		assert.Equal(t, "user/token", token)
+
+		require.NoError(t, c.Clear())
+		token, err = c.Load()
+		require.NoError(t, err)
+		assert.Empty(t, token)
+
+		require.NoError(t, c.Clear(), "clearing an empty cache must not fail")
+	})
+
+	t.Run("Uses a different file per account", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+
+		assert.Equal(t, tokencache.New(dir, "https://example.com", "user").Path(), tokencache.New(dir, "https://example.com", "user").Path())
+		assert.NotEqual(t, tokencache.New(dir, "https://example.com", "user").Path(), tokencache.New(dir, "https://example.com", "other").Path())
+		assert.NotEqual(t, tokencache.New(dir, "https://example.com", "user").Path(), tokencache.New(dir, "https://other.com", "user").Path())
+	})
+}
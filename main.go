@@ -7,12 +7,13 @@ import (
 	"os"
 
 	"github.com/brpaz/freshrss-cleaner/cmd"
+	"github.com/brpaz/freshrss-cleaner/cmd/cmdutil"
 )
 
 func main() {
 	rootCmd := cmd.NewRootCmd()
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(cmdutil.ExitCode(err))
 	}
 }